@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type ExchangeRateRequest struct {
+	Rates []calculator.ExchangeRate `json:"rates" required:"true"`
+}
+
+type ExchangeRateResponse struct {
+	Rates []calculator.ExchangeRate `json:"rates"`
+}
+
+func ExchangeRateHandler(c echo.Context) error {
+	var req ExchangeRateRequest
+	err := strictjson.Bind(c, &req)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	if len(req.Rates) == 0 {
+		return apperror.Respond(c, http.StatusBadRequest, apperror.New(apperror.InvalidRequest, "rates", "rates must not be empty"))
+	}
+
+	err = calculator.SetExchangeRates(req.Rates)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	res := ExchangeRateResponse{Rates: calculator.ExchangeRates()}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -1,13 +1,15 @@
 package admin
 
 import (
+	"github.com/TonRat/assessment-tax/apperror"
 	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/strictjson"
 	"github.com/labstack/echo/v4"
 	"net/http"
 )
 
 type KReceiptRequest struct {
-	Amount float64 `json:"amount"`
+	Amount float64 `json:"amount" required:"true"`
 }
 
 type KReceiptResepond struct {
@@ -16,13 +18,13 @@ type KReceiptResepond struct {
 
 func KReceiptHandler(c echo.Context) error {
 	var req KReceiptRequest
-	err := c.Bind(&req)
+	err := strictjson.Bind(c, &req)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, Err{Message: err.Error()})
+		return apperror.Respond(c, http.StatusBadRequest, err)
 	}
 
 	if req.Amount < 0 {
-		return c.JSON(http.StatusBadRequest, Err{Message: "kReceipt amount must be greater than or equal to 0"})
+		return apperror.Respond(c, http.StatusBadRequest, apperror.New(apperror.OutOfRange, "amount", "kReceipt amount must be greater than or equal to 0"))
 	}
 
 	calculator.InitialKReceipt = req.Amount
@@ -1,32 +1,30 @@
 package admin
 
 import (
+	"github.com/TonRat/assessment-tax/apperror"
 	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/strictjson"
 	"github.com/labstack/echo/v4"
 	"net/http"
 )
 
 type DeductionRequest struct {
-	Amount float64 `json:"amount"`
+	Amount float64 `json:"amount" required:"true"`
 }
 
 type DeductionResponse struct {
 	PersonalDeduction float64 `json:"personalDeduction"`
 }
 
-type Err struct {
-	Message string `json:"message"`
-}
-
 func PersonalDeductionHandler(c echo.Context) error {
 	var req DeductionRequest
-	err := c.Bind(&req)
+	err := strictjson.Bind(c, &req)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, Err{Message: err.Error()})
+		return apperror.Respond(c, http.StatusBadRequest, err)
 	}
 
 	if req.Amount < 10000 {
-		return c.JSON(http.StatusBadRequest, Err{Message: "personalDeduction amount must be greater than or equal to 10,000"})
+		return apperror.Respond(c, http.StatusBadRequest, apperror.New(apperror.OutOfRange, "amount", "personalDeduction amount must be greater than or equal to 10,000"))
 	}
 
 	calculator.InitialPersonalDeduction = req.Amount
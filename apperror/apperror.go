@@ -0,0 +1,239 @@
+// Package apperror holds the errors shared by the calculator and the
+// handlers. Each error has a stable code that clients can match on, the path
+// of the field that caused it and optional details, and is returned over
+// HTTP as RFC 7807 problem+json.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"github.com/TonRat/assessment-tax/i18n"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type Code string
+
+const (
+	InvalidRequest    Code = "invalid_request"
+	InvalidFormat     Code = "invalid_format"
+	InvalidType       Code = "invalid_type"
+	UnknownField      Code = "unknown_field"
+	MissingField      Code = "missing_field"
+	OutOfRange        Code = "out_of_range"
+	UnknownType       Code = "unknown_type"
+	ConflictingFields Code = "conflicting_fields"
+	InvalidChecksum   Code = "invalid_checksum"
+	MissingRate       Code = "missing_exchange_rate"
+	InvalidCSV        Code = "invalid_csv"
+	Unauthorized      Code = "unauthorized"
+	NotFound          Code = "not_found"
+	MethodNotAllowed  Code = "method_not_allowed"
+	Internal          Code = "internal_error"
+	ValidationFailed  Code = "validation_failed"
+)
+
+type Error struct {
+	Code    Code
+	Field   string
+	Message string
+	Details map[string]interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func New(code Code, field, message string) *Error {
+	return &Error{Code: code, Field: field, Message: message}
+}
+
+// WithDetail returns a copy of the error with the detail added.
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	err := *e
+	err.Details = map[string]interface{}{}
+	for k, v := range e.Details {
+		err.Details[k] = v
+	}
+	err.Details[key] = value
+	return &err
+}
+
+// Errors are all the problems found in one request.
+type Errors []*Error
+
+func (errs Errors) Error() string {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Err returns nil when there is no error, the error itself when there is one
+// and errs otherwise.
+func (errs Errors) Err() error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errs
+	}
+}
+
+// Prefix puts the error under a parent field, e.g. "spouse1" turns
+// "incomes[0].amount" into "spouse1.incomes[0].amount". Errors without a
+// code become invalid_request.
+func Prefix(parent string, err error) error {
+	var errs Errors
+	if errors.As(err, &errs) {
+		prefixed := Errors{}
+		for _, e := range errs {
+			prefixed = append(prefixed, prefix(parent, e))
+		}
+		return prefixed
+	}
+	return prefix(parent, As(err, InvalidRequest))
+}
+
+func prefix(parent string, e *Error) *Error {
+	prefixed := *e
+	prefixed.Message = parent + ": " + e.Message
+	prefixed.Field = parent
+	if e.Field != "" {
+		prefixed.Field = parent + "." + e.Field
+	}
+	return &prefixed
+}
+
+// As returns err as an *Error, or wraps its message with the code.
+func As(err error, code Code) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return New(code, "", err.Error())
+}
+
+type ProblemError struct {
+	Code    Code                   `json:"code"`
+	Field   string                 `json:"field,omitempty"`
+	Detail  string                 `json:"detail"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+type Problem struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail"`
+	Instance string                 `json:"instance,omitempty"`
+	Code     Code                   `json:"code"`
+	Field    string                 `json:"field,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+	Errors   []ProblemError         `json:"errors,omitempty"`
+}
+
+const ProblemContentType = "application/problem+json"
+
+func NewProblem(status int, err error) Problem {
+	code := InvalidRequest
+	if status >= http.StatusInternalServerError {
+		code = Internal
+	}
+	var errs Errors
+	if errors.As(err, &errs) {
+		problem := Problem{
+			Type:   "urn:assessment-tax:error:" + string(ValidationFailed),
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: "request has " + strconv.Itoa(len(errs)) + " problems",
+			Code:   ValidationFailed,
+			Errors: []ProblemError{},
+		}
+		for _, e := range errs {
+			problem.Errors = append(problem.Errors, ProblemError{Code: e.Code, Field: e.Field, Detail: e.Message, Details: e.Details})
+		}
+		return problem
+	}
+
+	e := As(err, code)
+	return Problem{
+		Type:    "urn:assessment-tax:error:" + string(e.Code),
+		Title:   http.StatusText(status),
+		Status:  status,
+		Detail:  e.Message,
+		Code:    e.Code,
+		Field:   e.Field,
+		Details: e.Details,
+	}
+}
+
+// Localize returns the problem with its title and details in lang.
+func (p Problem) Localize(lang string) Problem {
+	p.Title = i18n.Text(lang, p.Title)
+	p.Detail = i18n.Text(lang, p.Detail)
+	if p.Errors != nil {
+		localized := make([]ProblemError, len(p.Errors))
+		for i, e := range p.Errors {
+			e.Detail = i18n.Text(lang, e.Detail)
+			localized[i] = e
+		}
+		p.Errors = localized
+	}
+	return p
+}
+
+// Respond writes err as problem+json with the request path as instance, in
+// the language of the Accept-Language header.
+func Respond(c echo.Context, status int, err error) error {
+	lang := i18n.Negotiate(c.Request(), c.Response())
+	problem := NewProblem(status, err).Localize(lang)
+	problem.Instance = c.Request().URL.Path
+	c.Response().Header().Set(echo.HeaderContentType, ProblemContentType)
+	return c.JSON(status, problem)
+}
+
+// Index is the path of a field of the i-th element of a list, e.g.
+// Index("incomes", 0, "amount") is "incomes[0].amount".
+func Index(list string, i int, field string) string {
+	return list + "[" + strconv.Itoa(i) + "]." + field
+}
+
+// HTTPErrorHandler writes the errors of echo itself, like an unknown route or
+// failed basic auth, as problem+json.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status := http.StatusInternalServerError
+	e := New(Internal, "", http.StatusText(status))
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		status = he.Code
+		e = New(statusCode(status), "", fmt.Sprint(he.Message))
+	}
+	if err := Respond(c, status, e); err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+func statusCode(status int) Code {
+	switch {
+	case status == http.StatusUnauthorized:
+		return Unauthorized
+	case status == http.StatusNotFound:
+		return NotFound
+	case status == http.StatusMethodNotAllowed:
+		return MethodNotAllowed
+	case status >= http.StatusInternalServerError:
+		return Internal
+	default:
+		return InvalidRequest
+	}
+}
@@ -0,0 +1,82 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestPrefix(t *testing.T) {
+	t.Run("PrefixField", func(t *testing.T) {
+		err := Prefix("spouse1", New(OutOfRange, Index("incomes", 0, "amount"), "income amount must be greater than or equal to 0"))
+
+		expected := &Error{Code: OutOfRange, Field: "spouse1.incomes[0].amount", Message: "spouse1: income amount must be greater than or equal to 0"}
+		assert.Equal(t, expected, err, "Wrong prefixed error")
+	})
+
+	t.Run("PrefixUntypedError", func(t *testing.T) {
+		err := Prefix("base", errors.New("something went wrong"))
+
+		expected := &Error{Code: InvalidRequest, Field: "base", Message: "base: something went wrong"}
+		assert.Equal(t, expected, err, "Wrong prefixed error")
+	})
+}
+
+func TestWithDetail(t *testing.T) {
+	err := New(InvalidFormat, "wht", "wht must be a number")
+
+	detailed := err.WithDetail("row", 2)
+
+	assert.Equal(t, map[string]interface{}{"row": 2}, detailed.Details, "Wrong details")
+	assert.Nil(t, err.Details, "Original error should not change")
+}
+
+func TestRespond(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	err := Respond(c, http.StatusBadRequest, New(OutOfRange, "wht", "wht must be between 0 and totalIncome").WithDetail("row", 2))
+	assert.NoError(t, err)
+
+	var res map[string]interface{}
+	err = json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	expected := map[string]interface{}{
+		"type":     "urn:assessment-tax:error:out_of_range",
+		"title":    "Bad Request",
+		"status":   400.0,
+		"detail":   "wht must be between 0 and totalIncome",
+		"instance": "/tax/calculations",
+		"code":     "out_of_range",
+		"field":    "wht",
+		"details":  map[string]interface{}{"row": 2.0},
+	}
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, expected, res)
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	var res Problem
+	err := json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, NotFound, res.Code)
+	assert.Equal(t, "Not Found", res.Detail)
+}
@@ -0,0 +1,136 @@
+package calculator
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"math"
+)
+
+type AllowanceAdvice struct {
+	AllowanceType   string   `json:"allowanceType"`
+	Amount          float64  `json:"amount"`
+	Cap             float64  `json:"cap"`
+	Headroom        float64  `json:"headroom"`
+	TaxSavedPerBaht float64  `json:"taxSavedPerBaht"`
+	TaxSaved        float64  `json:"taxSaved"`
+	Savings         []Saving `json:"savings"`
+}
+
+// Saving is the tax saved by deducting Amount more at the rate of one tax
+// level.
+type Saving struct {
+	Amount   float64 `json:"amount"`
+	Rate     float64 `json:"rate"`
+	TaxSaved float64 `json:"taxSaved"`
+}
+
+// AdviseAllowances reports how much more of each allowance can be deducted
+// before its cap and how much tax it saves. Deducting more lowers the taxable
+// income through lower tax levels, so the headroom is split into savings at
+// each level's rate. incomes are the income details behind totalIncome, and
+// when the alternative method sets the tax, the saving is only what the tax
+// actually drops by. Each allowance is advised on its own with the others
+// left as they are.
+func AdviseAllowances(totalIncome float64, incomes []IncomeDetail, allowances []Allowance) ([]AllowanceAdvice, error) {
+	if totalIncome < 0.0 {
+		return nil, apperror.New(apperror.OutOfRange, "totalIncome", "totalIncome must be greater than or equal to 0")
+	}
+	deductions, err := deductAllowances(allowances, 1.0)
+	if err != nil {
+		return nil, err
+	}
+	before, err := assessIncome(totalIncome, totalIncome, incomes, allowances, 1.0)
+	if err != nil {
+		return nil, err
+	}
+	taxableIncome := totalIncome
+	applied := map[string]float64{}
+	for _, step := range deductions {
+		taxableIncome -= step.Amount
+		applied[step.Step] = step.Amount
+	}
+
+	advice := []AllowanceAdvice{}
+	for _, allowanceType := range allowanceTypes {
+		allowanceAdvice := AllowanceAdvice{
+			AllowanceType: allowanceType,
+			Amount:        applied[allowanceType],
+			Cap:           allowanceCap(allowanceType),
+		}
+		allowanceAdvice.Headroom = math.Max(allowanceAdvice.Cap-allowanceAdvice.Amount, 0)
+
+		// the last allowance of a type is the one deducted
+		after, err := assessIncome(totalIncome, totalIncome, incomes, append(append([]Allowance{}, allowances...), Allowance{AllowanceType: allowanceType, Amount: allowanceAdvice.Cap}), 1.0)
+		if err != nil {
+			return nil, err
+		}
+		allowanceAdvice.Savings = limitSavings(splitSavings(taxableIncome, allowanceAdvice.Headroom), before.Tax-after.Tax)
+		for _, saving := range allowanceAdvice.Savings {
+			allowanceAdvice.TaxSaved += saving.TaxSaved
+		}
+		if len(allowanceAdvice.Savings) > 0 {
+			allowanceAdvice.TaxSavedPerBaht = allowanceAdvice.Savings[0].Rate
+		}
+		advice = append(advice, allowanceAdvice)
+	}
+	return advice, nil
+}
+
+// splitSavings walks the taxable income down by amount, one tax level at a
+// time. Any amount deducted below zero taxable income saves nothing.
+func splitSavings(taxableIncome, amount float64) []Saving {
+	savings := []Saving{}
+	for i := len(taxBrackets) - 1; i >= 0 && amount > 0; i-- {
+		bracket := taxBrackets[i]
+		if taxableIncome <= bracket.lower {
+			continue
+		}
+		base := math.Min(amount, taxableIncome-bracket.lower)
+		savings = appendSaving(savings, base, bracket.rate)
+		taxableIncome -= base
+		amount -= base
+	}
+	if amount > 0 {
+		savings = appendSaving(savings, amount, 0.0)
+	}
+	return savings
+}
+
+// limitSavings keeps the savings up to taxSaved, starting from the highest
+// rate, and moves the rest of the amount to a saving at rate 0. Savings that
+// are off from taxSaved by less than a satang are rounding and kept as they
+// are.
+func limitSavings(savings []Saving, taxSaved float64) []Saving {
+	total := 0.0
+	for _, saving := range savings {
+		total += saving.TaxSaved
+	}
+	if total-taxSaved < 0.01 {
+		return savings
+	}
+
+	limited := []Saving{}
+	remaining := math.Max(taxSaved, 0)
+	for _, saving := range savings {
+		amount := saving.Amount
+		if saving.Rate > 0 && remaining >= 0.01 {
+			base := math.Min(amount, remaining/saving.Rate)
+			limited = appendSaving(limited, base, saving.Rate)
+			remaining -= base * saving.Rate
+			amount -= base
+		}
+		if amount > 0 {
+			limited = appendSaving(limited, amount, 0.0)
+		}
+	}
+	return limited
+}
+
+func appendSaving(savings []Saving, amount, rate float64) []Saving {
+	if len(savings) > 0 && savings[len(savings)-1].Rate == rate {
+		last := &savings[len(savings)-1]
+		last.Amount += amount
+		last.TaxSaved = last.Amount * rate
+		return savings
+	}
+	return append(savings, Saving{Amount: amount, Rate: rate, TaxSaved: amount * rate})
+}
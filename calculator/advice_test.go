@@ -0,0 +1,112 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdviseAllowances(t *testing.T) {
+	t.Run("HeadroomCrossesTaxLevel", func(t *testing.T) {
+		allowances := []Allowance{{AllowanceType: "donation", Amount: 30000.0}}
+
+		advice, err := AdviseAllowances(600000.0, nil, allowances)
+
+		expectedAdvice := []AllowanceAdvice{
+			{
+				AllowanceType:   "k-receipt",
+				Amount:          0.0,
+				Cap:             50000.0,
+				Headroom:        50000.0,
+				TaxSavedPerBaht: 0.15,
+				TaxSaved:        5500.0,
+				Savings: []Saving{
+					{Amount: 10000.0, Rate: 0.15, TaxSaved: 1500.0},
+					{Amount: 40000.0, Rate: 0.1, TaxSaved: 4000.0},
+				},
+			},
+			{
+				AllowanceType:   "donation",
+				Amount:          30000.0,
+				Cap:             100000.0,
+				Headroom:        70000.0,
+				TaxSavedPerBaht: 0.15,
+				TaxSaved:        7500.0,
+				Savings: []Saving{
+					{Amount: 10000.0, Rate: 0.15, TaxSaved: 1500.0},
+					{Amount: 60000.0, Rate: 0.1, TaxSaved: 6000.0},
+				},
+			},
+		}
+
+		assert.Equal(t, expectedAdvice, advice, "Wrong advice")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("TaxSavedMatchesCalculateTax", func(t *testing.T) {
+		advice, err := AdviseAllowances(600000.0, nil, nil)
+		assert.Nil(t, err, "Should not be error")
+
+		taxBefore, _, _ := CalculateTax(600000.0, 0.0, nil)
+		taxAfter, _, _ := CalculateTax(600000.0, 0.0, []Allowance{{AllowanceType: "donation", Amount: 100000.0}})
+
+		assert.Equal(t, "donation", advice[1].AllowanceType)
+		assert.Equal(t, taxBefore-taxAfter, advice[1].TaxSaved, "Tax saved should be %.1f", taxBefore-taxAfter)
+	})
+
+	t.Run("CapReached", func(t *testing.T) {
+		allowances := []Allowance{{AllowanceType: "k-receipt", Amount: 80000.0}}
+
+		advice, err := AdviseAllowances(600000.0, nil, allowances)
+
+		assert.Equal(t, 0.0, advice[0].Headroom, "Headroom should be 0.0")
+		assert.Equal(t, 0.0, advice[0].TaxSavedPerBaht, "Tax saved per baht should be 0.0")
+		assert.Equal(t, []Saving{}, advice[0].Savings, "Should be no saving")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("NotTaxed", func(t *testing.T) {
+		advice, err := AdviseAllowances(100000.0, nil, nil)
+
+		expectedSavings := []Saving{{Amount: 50000.0, Rate: 0.0, TaxSaved: 0.0}}
+
+		assert.Equal(t, expectedSavings, advice[0].Savings, "Wrong savings")
+		assert.Equal(t, 0.0, advice[0].TaxSaved, "Tax saved should be 0.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("AlternativeMethod", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(8)", Amount: 10000000.0, ActualExpense: 9500000.0}}
+		_, netIncome, details, err := CalculateNetIncome(incomes)
+		assert.Nil(t, err, "Should not be error")
+
+		advice, err := AdviseAllowances(netIncome, details, nil)
+
+		// 0.5% of 10,000,000 = 50,000 is more than the progressive tax even
+		// before any donation
+		assert.Equal(t, "donation", advice[1].AllowanceType)
+		assert.Equal(t, 0.0, advice[1].TaxSaved, "Tax saved should be 0.0")
+		assert.Equal(t, 0.0, advice[1].TaxSavedPerBaht, "Tax saved per baht should be 0.0")
+		assert.Equal(t, []Saving{{Amount: 100000.0, Rate: 0.0, TaxSaved: 0.0}}, advice[1].Savings, "Wrong savings")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("AlternativeMethodAfterDeduction", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(8)", Amount: 7900000.0, ActualExpense: 7300000.0}}
+		_, netIncome, details, err := CalculateNetIncome(incomes)
+		assert.Nil(t, err, "Should not be error")
+
+		advice, err := AdviseAllowances(netIncome, details, nil)
+		assert.Nil(t, err, "Should not be error")
+
+		before, _ := CalculateTaxFromIncomes(incomes, nil, 0.0, nil)
+		after, _ := CalculateTaxFromIncomes(incomes, nil, 0.0, []Allowance{{AllowanceType: "donation", Amount: 100000.0}})
+
+		// the progressive tax 41,000 drops to 0.5% of 7,900,000 = 39,500
+		assert.InDelta(t, 1500.0, advice[1].TaxSaved, 0.01, "Tax saved should be 1500.0")
+		assert.InDelta(t, before.Tax-after.Tax, advice[1].TaxSaved, 0.01, "Tax saved should match the calculation")
+		assert.Len(t, advice[1].Savings, 2, "Should be saving at 15% then none")
+		assert.InDelta(t, 10000.0, advice[1].Savings[0].Amount, 0.01)
+		assert.Equal(t, 0.15, advice[1].Savings[0].Rate)
+	})
+}
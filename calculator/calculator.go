@@ -1,10 +1,13 @@
 package calculator
 
-import "errors"
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"math"
+)
 
 type Allowance struct {
-	AllowanceType string  `json:"allowanceType"`
-	Amount        float64 `json:"amount"`
+	AllowanceType string  `json:"allowanceType" required:"true"`
+	Amount        float64 `json:"amount" required:"true"`
 }
 
 type TaxLevel struct {
@@ -16,91 +19,185 @@ var (
 	InitialPersonalDeduction = 60000.0
 	InitialKReceipt          = 50000.0
 	donationMax              = 100000.0
+	spouseAllowanceMax       = 60000.0
 )
 
+type Step struct {
+	Step      string  `json:"step"`
+	Requested float64 `json:"requested,omitempty"`
+	Amount    float64 `json:"amount"`
+	Base      float64 `json:"base,omitempty"`
+	Rate      float64 `json:"rate,omitempty"`
+	Cap       float64 `json:"cap,omitempty"`
+	Note      string  `json:"note,omitempty"`
+}
+
+type taxBracket struct {
+	level string
+	lower float64
+	upper float64
+	rate  float64
+}
+
+var taxBrackets = []taxBracket{
+	{level: "0-150,000", lower: 0.0, upper: 150000.0, rate: 0.0},
+	{level: "150,001-500,000", lower: 150000.0, upper: 500000.0, rate: 0.1},
+	{level: "500,001-1,000,000", lower: 500000.0, upper: 1000000.0, rate: 0.15},
+	{level: "1,000,001-2,000,000", lower: 1000000.0, upper: 2000000.0, rate: 0.2},
+	{level: "2,000,001 ขึ้นไป", lower: 2000000.0, upper: math.Inf(1), rate: 0.35},
+}
+
 func CalculateTax(totalIncome, wht float64, allowances []Allowance) (float64, []TaxLevel, error) {
-	taxLevels := []TaxLevel{
-		{Level: "0-150,000", Tax: 0.0},
-		{Level: "150,001-500,000", Tax: 0.0},
-		{Level: "500,001-1,000,000", Tax: 0.0},
-		{Level: "1,000,001-2,000,000", Tax: 0.0},
-		{Level: "2,000,001 ขึ้นไป", Tax: 0.0},
+	result, err := CalculateTaxDetail(totalIncome, wht, allowances)
+	if err != nil {
+		return 0.0, nil, err
 	}
+	return result.Tax, result.TaxLevels, nil
+}
 
+// CalculateTaxDetail calculates the same tax as CalculateTax and also returns
+// the tax rate and every step taken to get there.
+func CalculateTaxDetail(totalIncome, wht float64, allowances []Allowance) (IncomeTaxResult, error) {
 	if wht < 0.0 || wht > totalIncome {
-		return 0.0, nil, errors.New("wht must be between 0 and totalIncome")
+		return IncomeTaxResult{}, apperror.New(apperror.OutOfRange, "wht", "wht must be between 0 and totalIncome")
+	}
+	result, err := calculateTax(totalIncome, allowances, 1.0)
+	if err != nil {
+		return IncomeTaxResult{}, err
 	}
+
+	result.GrossIncome = totalIncome
+	result.NetIncome = totalIncome
+	result.TaxRate = CalculateTaxRate(totalIncome, result.TaxableIncome, result.Tax)
+	result.Trace = append([]Step{{Step: "gross income", Amount: totalIncome}}, result.Trace...)
+	result.Trace = append(result.Trace, creditWht(result.Tax, wht)...)
+	result.Tax -= wht
+	return result, nil
+}
+
+// creditWht explains crediting wht against the tax and the final result.
+func creditWht(tax, wht float64) []Step {
+	result := Step{Step: "tax", Amount: tax - wht}
+	if tax-wht < 0 {
+		result = Step{Step: "tax refund", Amount: wht - tax}
+	}
+	return []Step{{Step: "wht credit", Amount: wht}, result}
+}
+
+// calculateTax applies personal deduction, allowances and the progressive
+// tax rate to income that has already had any expense deduction taken off.
+// The returned tax is before wht is credited. limitRatio scales the deduction
+// limits, 0.5 for a half-year period.
+func calculateTax(totalIncome float64, allowances []Allowance, limitRatio float64) (IncomeTaxResult, error) {
+	taxLevels := []TaxLevel{}
+	for _, bracket := range taxBrackets {
+		taxLevels = append(taxLevels, TaxLevel{Level: bracket.level, Tax: 0.0})
+	}
+
 	if totalIncome < 0.0 {
-		return 0.0, nil, errors.New("TotalIncome must be greater than 0")
+		return IncomeTaxResult{}, apperror.New(apperror.OutOfRange, "totalIncome", "totalIncome must be greater than or equal to 0")
 	}
 	if totalIncome == 0.0 {
-		return 0.0, taxLevels, nil
+		return IncomeTaxResult{TaxLevels: taxLevels, Trace: []Step{{Step: "taxable income", Amount: 0.0}}}, nil
+	}
+	taxableIncome := totalIncome
+	deductions, err := deductAllowances(allowances, limitRatio)
+	if err != nil {
+		return IncomeTaxResult{}, err
 	}
-	taxableIncome := totalIncome - InitialPersonalDeduction
+	for _, step := range deductions {
+		taxableIncome -= step.Amount
+	}
+	trace := append(deductions, Step{Step: "taxable income", Amount: taxableIncome})
+
+	tax := 0.0
+	for i, bracket := range taxBrackets {
+		if taxableIncome <= bracket.lower {
+			break
+		}
+		base := math.Min(taxableIncome, bracket.upper) - bracket.lower
+		taxLevels[i].Tax = base * bracket.rate
+		tax += taxLevels[i].Tax
+		trace = append(trace, Step{Step: "tax " + bracket.level, Amount: taxLevels[i].Tax, Base: base, Rate: bracket.rate})
+	}
+	trace = append(trace, Step{Step: "progressive tax", Amount: tax})
 
-	kReceiptAmount := 0.0
-	donationAmount := 0.0
+	return IncomeTaxResult{Tax: tax, TaxLevels: taxLevels, TaxableIncome: taxableIncome, Trace: trace}, nil
+}
+
+// deductAllowances returns personal deduction followed by the spouse,
+// k-receipt and donation allowance actually deducted after their caps, all
+// scaled by limitRatio.
+func deductAllowances(allowances []Allowance, limitRatio float64) ([]Step, error) {
+	spouse := Step{Step: "spouse", Cap: spouseAllowanceMax * limitRatio}
+	kReceipt := Step{Step: "k-receipt", Cap: allowanceCap("k-receipt") * limitRatio}
+	donation := Step{Step: "donation", Cap: allowanceCap("donation") * limitRatio}
 	// check k-receipt and donation
-	for _, allowance := range allowances {
+	for i, allowance := range allowances {
 		switch allowance.AllowanceType {
 		case "k-receipt":
 			if allowance.Amount < 0 {
-				return 0.0, nil, errors.New("kReceiptAmount must be greater than 0")
-			} else if allowance.Amount < InitialKReceipt {
-				kReceiptAmount = allowance.Amount
-			} else {
-				kReceiptAmount = InitialKReceipt
+				return nil, apperror.New(apperror.OutOfRange, apperror.Index("allowances", i, "amount"), "kReceiptAmount must be greater than 0")
 			}
+			kReceipt = capAllowance(kReceipt, allowance.Amount)
 
 		case "donation":
 			if allowance.Amount < 0 {
-				return 0.0, nil, errors.New("donation must be greater than 0")
-			} else if allowance.Amount < donationMax {
-				donationAmount = allowance.Amount
-			} else {
-				donationAmount = donationMax
+				return nil, apperror.New(apperror.OutOfRange, apperror.Index("allowances", i, "amount"), "donation must be greater than 0")
+			}
+			donation = capAllowance(donation, allowance.Amount)
+
+		case "spouse":
+			if allowance.Amount < 0 {
+				return nil, apperror.New(apperror.OutOfRange, apperror.Index("allowances", i, "amount"), "spouse must be greater than 0")
 			}
+			spouse = capAllowance(spouse, allowance.Amount)
 		}
 	}
 
-	// deduct k-receipt and donation
-	taxableIncome -= kReceiptAmount
-	taxableIncome -= donationAmount
+	deductions := []Step{{Step: "personal deduction", Amount: InitialPersonalDeduction * limitRatio}}
+	for _, step := range []Step{spouse, kReceipt, donation} {
+		if step.Note != "" {
+			deductions = append(deductions, step)
+		}
+	}
+	return deductions, nil
+}
 
-	tax := 0.0
+// allowanceTypes are the allowances deducted on top of personal deduction.
+var allowanceTypes = []string{"k-receipt", "donation"}
 
-	if taxableIncome <= 150000.0 {
-		tax = 0.0
-	} else if taxableIncome <= 500000.0 {
-		tax = (taxableIncome - 150000.0) * 0.1
-		taxLevels[1].Tax = tax
-	} else if taxableIncome <= 1000000.0 {
-		taxLevel_2 := (500000.0 - 150000.0) * 0.1
-		taxLevels[1].Tax = taxLevel_2
-		taxLevel_3 := (taxableIncome - 500000.0) * 0.15
-		taxLevels[2].Tax = taxLevel_3
-		tax = taxLevel_2 + taxLevel_3
-	} else if taxableIncome <= 2000000.0 {
-		taxLevel_2 := (500000.0 - 150000.0) * 0.1
-		taxLevels[1].Tax = taxLevel_2
-		taxLevel_3 := (1000000.0 - 500000.0) * 0.15
-		taxLevels[2].Tax = taxLevel_3
-		taxLevel_4 := (taxableIncome - 1000000.0) * 0.2
-		taxLevels[3].Tax = taxLevel_4
-		tax = taxLevel_2 + taxLevel_3 + taxLevel_4
-	} else {
-		taxLevel_2 := (500000.0 - 150000.0) * 0.1
-		taxLevels[1].Tax = taxLevel_2
-		taxLevel_3 := (1000000.0 - 500000.0) * 0.15
-		taxLevels[2].Tax = taxLevel_3
-		taxLevel_4 := (2000000.0 - 1000000.0) * 0.2
-		taxLevels[3].Tax = taxLevel_4
-		taxLevel_5 := (taxableIncome - 2000000.0) * 0.35
-		taxLevels[4].Tax = taxLevel_5
-		tax = taxLevel_2 + taxLevel_3 + taxLevel_4 + taxLevel_5
+func allowanceCap(allowanceType string) float64 {
+	switch allowanceType {
+	case "k-receipt":
+		return InitialKReceipt
+	case "donation":
+		return donationMax
 	}
+	return 0.0
+}
 
-	tax -= wht
+func capAllowance(step Step, amount float64) Step {
+	step.Requested = amount
+	step.Amount = amount
+	step.Note = "applied in full"
+	if amount > step.Cap {
+		step.Amount = step.Cap
+		step.Note = "clipped by " + step.Step + " cap"
+	}
+	return step
+}
 
-	return tax, taxLevels, nil
+// creditTax subtracts a credit from the tax and replaces the final tax or
+// tax refund step of the trace with the credit and the new final step.
+func creditTax(result IncomeTaxResult, credit Step) IncomeTaxResult {
+	tax := roundSatang(result.Tax - credit.Amount)
+	final := Step{Step: "tax", Amount: tax}
+	if tax < 0 {
+		final = Step{Step: "tax refund", Amount: -tax}
+	}
+	trace := append([]Step{}, result.Trace[:len(result.Trace)-1]...)
+	result.Trace = append(trace, credit, final)
+	result.Tax = tax
+	return result
 }
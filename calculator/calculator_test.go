@@ -337,3 +337,53 @@ func TestCalculateTaxLevel(t *testing.T) {
 		assert.Nil(t, err, "Should not be error")
 	})
 }
+func TestCalculateTaxDetail(t *testing.T) {
+	t.Run("AllowanceClipped", func(t *testing.T) {
+		totalIncome := 500000.0
+		wht := 0.0
+		allowances := []Allowance{
+			{AllowanceType: "k-receipt", Amount: 200000.0},
+			{AllowanceType: "donation", Amount: 100000.0},
+		}
+
+		result, err := CalculateTaxDetail(totalIncome, wht, allowances)
+
+		expectedTrace := []Step{
+			{Step: "gross income", Amount: 500000.0},
+			{Step: "personal deduction", Amount: 60000.0},
+			{Step: "k-receipt", Requested: 200000.0, Amount: 50000.0, Cap: 50000.0, Note: "clipped by k-receipt cap"},
+			{Step: "donation", Requested: 100000.0, Amount: 100000.0, Cap: 100000.0, Note: "applied in full"},
+			{Step: "taxable income", Amount: 290000.0},
+			{Step: "tax 0-150,000", Amount: 0.0, Base: 150000.0, Rate: 0.0},
+			{Step: "tax 150,001-500,000", Amount: 14000.0, Base: 140000.0, Rate: 0.1},
+			{Step: "progressive tax", Amount: 14000.0},
+			{Step: "wht credit", Amount: 0.0},
+			{Step: "tax", Amount: 14000.0},
+		}
+
+		assert.Equal(t, 14000.0, result.Tax, "Tax should be 14000.0")
+		assert.Equal(t, expectedTrace, result.Trace, "Wrong trace")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("Refund", func(t *testing.T) {
+		totalIncome := 100000.0
+		wht := 5000.0
+
+		result, err := CalculateTaxDetail(totalIncome, wht, nil)
+
+		expectedTrace := []Step{
+			{Step: "gross income", Amount: 100000.0},
+			{Step: "personal deduction", Amount: 60000.0},
+			{Step: "taxable income", Amount: 40000.0},
+			{Step: "tax 0-150,000", Amount: 0.0, Base: 40000.0, Rate: 0.0},
+			{Step: "progressive tax", Amount: 0.0},
+			{Step: "wht credit", Amount: 5000.0},
+			{Step: "tax refund", Amount: 5000.0},
+		}
+
+		assert.Equal(t, -5000.0, result.Tax, "Tax should be -5000.0")
+		assert.Equal(t, expectedTrace, result.Trace, "Wrong trace")
+		assert.Nil(t, err, "Should not be error")
+	})
+}
@@ -0,0 +1,219 @@
+package calculator
+
+import (
+	"encoding/csv"
+	"github.com/TonRat/assessment-tax/apperror"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ExchangeRate struct {
+	Currency string  `json:"currency" required:"true"`
+	Date     string  `json:"date" required:"true"`
+	Rate     float64 `json:"rate" required:"true"`
+}
+
+// ForeignIncome is income earned abroad and brought into Thailand on the
+// remittance date. Amount and ForeignTax are in the foreign currency.
+type ForeignIncome struct {
+	IncomeType     string  `json:"incomeType" required:"true"`
+	Currency       string  `json:"currency" required:"true"`
+	Amount         float64 `json:"amount" required:"true"`
+	RemittanceDate string  `json:"remittanceDate" required:"true"`
+	ForeignTax     float64 `json:"foreignTax"`
+}
+
+type ForeignIncomeDetail struct {
+	IncomeType     string  `json:"incomeType"`
+	Currency       string  `json:"currency"`
+	Amount         float64 `json:"amount"`
+	RemittanceDate string  `json:"remittanceDate"`
+	Rate           float64 `json:"rate"`
+	RateDate       string  `json:"rateDate"`
+	AmountTHB      float64 `json:"amountTHB"`
+	ForeignTaxTHB  float64 `json:"foreignTaxTHB"`
+}
+
+type ForeignTaxCredit struct {
+	ForeignTax      float64 `json:"foreignTax"`
+	AttributableTax float64 `json:"attributableTax"`
+	Credit          float64 `json:"credit"`
+}
+
+var (
+	exchangeRatesMu sync.RWMutex
+	// exchangeRates holds the baht rate of each currency sorted by date.
+	exchangeRates = map[string][]ExchangeRate{}
+)
+
+// SetExchangeRates adds the rates to the table, replacing any rate of the
+// same currency and date.
+func SetExchangeRates(rates []ExchangeRate) error {
+	for i := range rates {
+		rates[i].Currency = strings.ToUpper(rates[i].Currency)
+		if len(rates[i].Currency) != 3 {
+			return apperror.New(apperror.InvalidFormat, apperror.Index("rates", i, "currency"), "currency must be a 3-letter code")
+		}
+		if _, err := time.Parse(dateLayout, rates[i].Date); err != nil {
+			return apperror.New(apperror.InvalidFormat, apperror.Index("rates", i, "date"), "date must be in YYYY-MM-DD format")
+		}
+		if rates[i].Rate <= 0 || math.IsNaN(rates[i].Rate) || math.IsInf(rates[i].Rate, 0) {
+			return apperror.New(apperror.OutOfRange, apperror.Index("rates", i, "rate"), "rate must be greater than 0")
+		}
+	}
+
+	exchangeRatesMu.Lock()
+	defer exchangeRatesMu.Unlock()
+	for _, rate := range rates {
+		table := exchangeRates[rate.Currency]
+		i := sort.Search(len(table), func(i int) bool { return table[i].Date >= rate.Date })
+		if i < len(table) && table[i].Date == rate.Date {
+			table[i] = rate
+			continue
+		}
+		table = append(table, ExchangeRate{})
+		copy(table[i+1:], table[i:])
+		table[i] = rate
+		exchangeRates[rate.Currency] = table
+	}
+	return nil
+}
+
+// ExchangeRates returns the rate table sorted by currency and date.
+func ExchangeRates() []ExchangeRate {
+	exchangeRatesMu.RLock()
+	defer exchangeRatesMu.RUnlock()
+	currencies := []string{}
+	for currency := range exchangeRates {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	rates := []ExchangeRate{}
+	for _, currency := range currencies {
+		rates = append(rates, exchangeRates[currency]...)
+	}
+	return rates
+}
+
+// ReadExchangeRates reads a rate table in CSV with the header
+// currency,date,rate.
+func ReadExchangeRates(r io.Reader) ([]ExchangeRate, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != "currency,date,rate" {
+		return nil, apperror.New(apperror.InvalidCSV, "", "exchange rate header must be currency,date,rate")
+	}
+
+	rates := []ExchangeRate{}
+	for i, record := range records[1:] {
+		rate, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, apperror.New(apperror.InvalidFormat, "rate", "line "+strconv.Itoa(i+2)+": rate must be a number").WithDetail("line", i+2)
+		}
+		rates = append(rates, ExchangeRate{Currency: record[0], Date: record[1], Rate: rate})
+	}
+	return rates, nil
+}
+
+// lookupExchangeRate finds the latest rate on or before the date. Baht is
+// always 1.
+func lookupExchangeRate(currency, date string) (ExchangeRate, bool) {
+	if currency == "THB" {
+		return ExchangeRate{Currency: currency, Date: date, Rate: 1.0}, true
+	}
+
+	exchangeRatesMu.RLock()
+	defer exchangeRatesMu.RUnlock()
+	table := exchangeRates[currency]
+	i := sort.Search(len(table), func(i int) bool { return table[i].Date > date })
+	if i == 0 {
+		return ExchangeRate{}, false
+	}
+	return table[i-1], true
+}
+
+// ConvertForeignIncomes converts foreign income to baht at the rate of the
+// remittance date, so it can be assessed with the other incomes of the year.
+// Only income remitted within TaxYear is taxed in this return.
+func ConvertForeignIncomes(foreignIncomes []ForeignIncome) ([]Income, []ForeignIncomeDetail, error) {
+	incomes := []Income{}
+	details := []ForeignIncomeDetail{}
+	for i, foreign := range foreignIncomes {
+		if _, ok := expenseRules[foreign.IncomeType]; !ok {
+			return nil, nil, apperror.New(apperror.UnknownType, apperror.Index("foreignIncomes", i, "incomeType"), "incomeType must be one of 40(1) to 40(8)")
+		}
+		if foreign.Amount < 0 {
+			return nil, nil, apperror.New(apperror.OutOfRange, apperror.Index("foreignIncomes", i, "amount"), "amount must be greater than or equal to 0")
+		}
+		if foreign.ForeignTax < 0 || foreign.ForeignTax > foreign.Amount {
+			return nil, nil, apperror.New(apperror.OutOfRange, apperror.Index("foreignIncomes", i, "foreignTax"), "foreignTax must be between 0 and amount")
+		}
+		remittedOn, err := time.Parse(dateLayout, foreign.RemittanceDate)
+		if err != nil {
+			return nil, nil, apperror.New(apperror.InvalidFormat, apperror.Index("foreignIncomes", i, "remittanceDate"), "remittanceDate must be in YYYY-MM-DD format")
+		}
+		if remittedOn.Year() != TaxYear {
+			return nil, nil, apperror.New(apperror.OutOfRange, apperror.Index("foreignIncomes", i, "remittanceDate"), "remittanceDate must be in tax year "+strconv.Itoa(thaiYear(TaxYear)))
+		}
+
+		currency := strings.ToUpper(foreign.Currency)
+		rate, ok := lookupExchangeRate(currency, foreign.RemittanceDate)
+		if !ok {
+			return nil, nil, apperror.New(apperror.MissingRate, apperror.Index("foreignIncomes", i, "currency"), "no exchange rate for "+currency+" on or before "+foreign.RemittanceDate)
+		}
+
+		amount := roundSatang(foreign.Amount * rate.Rate)
+		incomes = append(incomes, Income{IncomeType: foreign.IncomeType, Amount: amount})
+		details = append(details, ForeignIncomeDetail{
+			IncomeType:     foreign.IncomeType,
+			Currency:       currency,
+			Amount:         foreign.Amount,
+			RemittanceDate: foreign.RemittanceDate,
+			Rate:           rate.Rate,
+			RateDate:       rate.Date,
+			AmountTHB:      amount,
+			ForeignTaxTHB:  roundSatang(foreign.ForeignTax * rate.Rate),
+		})
+	}
+	return incomes, details, nil
+}
+
+// CreditForeignTax credits the foreign tax paid against the Thai tax, after
+// wht. The credit is capped at the Thai tax attributable to the foreign
+// income, which is the assessed tax in proportion to its share of gross
+// income.
+func CreditForeignTax(result IncomeTaxResult, details []ForeignIncomeDetail) (IncomeTaxResult, ForeignTaxCredit, error) {
+	foreignIncome := 0.0
+	credit := ForeignTaxCredit{}
+	for _, detail := range details {
+		foreignIncome += detail.AmountTHB
+		credit.ForeignTax += detail.ForeignTaxTHB
+	}
+	if foreignIncome > result.GrossIncome {
+		return IncomeTaxResult{}, ForeignTaxCredit{}, apperror.New(apperror.ConflictingFields, "foreignIncomes", "foreign income must be part of gross income")
+	}
+
+	assessedTax := result.TaxMethod.ProgressiveTax
+	if result.TaxMethod.Applied == "alternative" {
+		assessedTax = result.TaxMethod.AlternativeTax
+	}
+	if result.GrossIncome > 0 {
+		credit.AttributableTax = roundSatang(assessedTax * foreignIncome / result.GrossIncome)
+	}
+	credit.Credit = math.Min(credit.ForeignTax, credit.AttributableTax)
+
+	note := "applied in full"
+	if credit.Credit < credit.ForeignTax {
+		note = "clipped by Thai tax attributable to foreign income"
+	}
+	result = creditTax(result, Step{Step: "foreign tax credit", Requested: credit.ForeignTax, Amount: credit.Credit, Cap: credit.AttributableTax, Note: note})
+	return result, credit, nil
+}
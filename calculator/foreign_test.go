@@ -0,0 +1,123 @@
+package calculator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertForeignIncomes(t *testing.T) {
+	err := SetExchangeRates([]ExchangeRate{
+		{Currency: "usd", Date: "2024-04-01", Rate: 36.45},
+		{Currency: "USD", Date: "2024-01-02", Rate: 34.2},
+	})
+	assert.Nil(t, err, "Should not be error")
+
+	t.Run("UseLatestRateOnOrBeforeRemittance", func(t *testing.T) {
+		foreignIncomes := []ForeignIncome{{IncomeType: "40(2)", Currency: "USD", Amount: 10000.0, RemittanceDate: "2024-05-15", ForeignTax: 1000.0}}
+
+		incomes, details, err := ConvertForeignIncomes(foreignIncomes)
+
+		expectedDetails := []ForeignIncomeDetail{{
+			IncomeType:     "40(2)",
+			Currency:       "USD",
+			Amount:         10000.0,
+			RemittanceDate: "2024-05-15",
+			Rate:           36.45,
+			RateDate:       "2024-04-01",
+			AmountTHB:      364500.0,
+			ForeignTaxTHB:  36450.0,
+		}}
+		assert.Equal(t, []Income{{IncomeType: "40(2)", Amount: 364500.0}}, incomes, "Wrong converted income")
+		assert.Equal(t, expectedDetails, details, "Wrong foreign income detail")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("NoRateBeforeRemittance", func(t *testing.T) {
+		foreignIncomes := []ForeignIncome{{IncomeType: "40(2)", Currency: "EUR", Amount: 10000.0, RemittanceDate: "2024-05-15"}}
+
+		_, _, err := ConvertForeignIncomes(foreignIncomes)
+
+		assert.Equal(t, "no exchange rate for EUR on or before 2024-05-15", err.Error(), "Should be error")
+	})
+
+	t.Run("RemittedOutsideTaxYear", func(t *testing.T) {
+		foreignIncomes := []ForeignIncome{{IncomeType: "40(2)", Currency: "USD", Amount: 10000.0, RemittanceDate: "2025-01-02"}}
+
+		_, _, err := ConvertForeignIncomes(foreignIncomes)
+
+		assert.Equal(t, "remittanceDate must be in tax year 2567", err.Error(), "Should be error")
+	})
+
+	t.Run("TaxYear", func(t *testing.T) {
+		taxYear := TaxYear
+		TaxYear = 2025
+		defer func() { TaxYear = taxYear }()
+
+		foreignIncomes := []ForeignIncome{{IncomeType: "40(2)", Currency: "USD", Amount: 10000.0, RemittanceDate: "2024-05-15"}}
+
+		_, _, err := ConvertForeignIncomes(foreignIncomes)
+
+		assert.Equal(t, "remittanceDate must be in tax year 2568", err.Error(), "Should be error")
+	})
+}
+
+func TestCreditForeignTax(t *testing.T) {
+	err := SetExchangeRates([]ExchangeRate{{Currency: "USD", Date: "2024-04-01", Rate: 36.45}})
+	assert.Nil(t, err, "Should not be error")
+
+	calculate := func(foreignTax float64) (IncomeTaxResult, ForeignTaxCredit, error) {
+		converted, details, err := ConvertForeignIncomes([]ForeignIncome{{IncomeType: "40(2)", Currency: "USD", Amount: 10000.0, RemittanceDate: "2024-05-15", ForeignTax: foreignTax}})
+		if err != nil {
+			return IncomeTaxResult{}, ForeignTaxCredit{}, err
+		}
+		incomes := append([]Income{{IncomeType: "40(1)", Amount: 600000.0}}, converted...)
+		result, err := CalculateTaxFromIncomes(incomes, nil, 0.0, nil)
+		if err != nil {
+			return IncomeTaxResult{}, ForeignTaxCredit{}, err
+		}
+		return CreditForeignTax(result, details)
+	}
+
+	t.Run("CreditAppliedInFull", func(t *testing.T) {
+		result, credit, err := calculate(100.0)
+
+		assert.Equal(t, ForeignTaxCredit{ForeignTax: 3645.0, AttributableTax: 30488.37, Credit: 3645.0}, credit, "Wrong foreign tax credit")
+		assert.Equal(t, 77030.0, result.Tax, "Tax should be 77030.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("CreditClippedByAttributableTax", func(t *testing.T) {
+		result, credit, err := calculate(1000.0)
+
+		expectedSteps := []Step{
+			{Step: "foreign tax credit", Requested: 36450.0, Amount: 30488.37, Cap: 30488.37, Note: "clipped by Thai tax attributable to foreign income"},
+			{Step: "tax", Amount: 50186.63},
+		}
+		// 80,675 Thai tax on 964,500 gross income, of which 364,500 is foreign
+		assert.Equal(t, ForeignTaxCredit{ForeignTax: 36450.0, AttributableTax: 30488.37, Credit: 30488.37}, credit, "Wrong foreign tax credit")
+		assert.Equal(t, 50186.63, result.Tax, "Wrong tax")
+		assert.Equal(t, expectedSteps, result.Trace[len(result.Trace)-2:], "Wrong trace")
+		assert.Nil(t, err, "Should not be error")
+	})
+}
+
+func TestReadExchangeRates(t *testing.T) {
+	t.Run("ReadRateTable", func(t *testing.T) {
+		rates, err := ReadExchangeRates(strings.NewReader("currency,date,rate\nUSD,2024-01-02,34.20\nJPY,2024-01-02,0.2420\n"))
+
+		expected := []ExchangeRate{
+			{Currency: "USD", Date: "2024-01-02", Rate: 34.2},
+			{Currency: "JPY", Date: "2024-01-02", Rate: 0.242},
+		}
+		assert.Equal(t, expected, rates, "Wrong exchange rates")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("WrongHeader", func(t *testing.T) {
+		_, err := ReadExchangeRates(strings.NewReader("currency,rate\nUSD,34.20\n"))
+
+		assert.Equal(t, "exchange rate header must be currency,date,rate", err.Error(), "Should be error")
+	})
+}
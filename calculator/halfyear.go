@@ -0,0 +1,47 @@
+package calculator
+
+import "github.com/TonRat/assessment-tax/apperror"
+
+// halfYearLimitRatio halves the personal deduction, allowance caps and the
+// alternative method threshold for the half-year return (ภ.ง.ด.94).
+var halfYearLimitRatio = 0.5
+
+var halfYearIncomeTypes = map[string]bool{"40(5)": true, "40(6)": true, "40(7)": true, "40(8)": true}
+
+// CalculateHalfYearTax calculates the half-year tax on 40(5)-40(8) income
+// earned from January to June, using half the deduction limits.
+func CalculateHalfYearTax(incomes []Income, wht float64, allowances []Allowance) (IncomeTaxResult, error) {
+	for i, income := range incomes {
+		if !halfYearIncomeTypes[income.IncomeType] {
+			return IncomeTaxResult{}, apperror.New(apperror.ConflictingFields, apperror.Index("incomes", i, "incomeType"), "half-year period only allows income 40(5) to 40(8)")
+		}
+	}
+
+	grossIncome, netIncome, details, incomeTrace, err := deductExpenses(incomes)
+	if err != nil {
+		return IncomeTaxResult{}, err
+	}
+	if wht < 0.0 || wht > grossIncome {
+		return IncomeTaxResult{}, apperror.New(apperror.OutOfRange, "wht", "wht must be between 0 and totalIncome")
+	}
+
+	result, err := assessIncome(grossIncome, netIncome, details, allowances, halfYearLimitRatio)
+	if err != nil {
+		return IncomeTaxResult{}, err
+	}
+
+	result.Trace = append(incomeTrace, result.Trace...)
+	result.Trace = append(result.Trace, creditWht(result.Tax, wht)...)
+	result.Tax -= wht
+	return result, nil
+}
+
+// CreditHalfYearTax credits the half-year tax already paid against the
+// annual tax, after wht.
+func CreditHalfYearTax(result IncomeTaxResult, halfYearTax float64) (IncomeTaxResult, error) {
+	if halfYearTax < 0 {
+		return IncomeTaxResult{}, apperror.New(apperror.OutOfRange, "halfYearTax", "halfYearTax must be greater than or equal to 0")
+	}
+
+	return creditTax(result, Step{Step: "half-year tax credit", Amount: halfYearTax}), nil
+}
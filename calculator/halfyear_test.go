@@ -0,0 +1,80 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateHalfYearTax(t *testing.T) {
+	t.Run("HalfPersonalDeduction", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(8)", Amount: 600000.0}}
+
+		result, err := CalculateHalfYearTax(incomes, 0.0, nil)
+
+		// 600,000 - 360,000 (expense) - 30,000 (half personal deduction) = 210,000
+		assert.Equal(t, 6000.0, result.Tax, "Tax should be 6000.0")
+		assert.Equal(t, 210000.0, result.TaxableIncome, "Taxable income should be 210000.0")
+		assert.Equal(t, "progressive", result.TaxMethod.Applied, "Progressive should be applied")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("HalfAllowanceCap", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(8)", Amount: 600000.0}}
+		allowances := []Allowance{{AllowanceType: "donation", Amount: 100000.0}}
+
+		result, err := CalculateHalfYearTax(incomes, 0.0, allowances)
+
+		assert.Equal(t, 1000.0, result.Tax, "Tax should be 1000.0")
+		assert.Contains(t, result.Trace, Step{Step: "donation", Requested: 100000.0, Amount: 50000.0, Cap: 50000.0, Note: "clipped by donation cap"})
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("HalfAlternativeThreshold", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(5)", Amount: 50000.0}}
+
+		result, err := CalculateHalfYearTax(incomes, 0.0, nil)
+
+		assert.Equal(t, "income other than 40(1) is less than 60,000, alternative method not applicable", result.TaxMethod.Explanation)
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("SalaryNotAllowed", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(1)", Amount: 600000.0}}
+
+		_, err := CalculateHalfYearTax(incomes, 0.0, nil)
+
+		assert.Equal(t, "half-year period only allows income 40(5) to 40(8)", err.Error(), "Should be error")
+	})
+}
+
+func TestCreditHalfYearTax(t *testing.T) {
+	t.Run("CreditedAfterWht", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(8)", Amount: 1200000.0}}
+		result, err := CalculateTaxFromIncomes(incomes, nil, 1000.0, nil)
+		assert.Nil(t, err, "Should not be error")
+
+		result, err = CreditHalfYearTax(result, 6000.0)
+
+		expectedTrace := []Step{
+			{Step: "wht credit", Amount: 1000.0},
+			{Step: "half-year tax credit", Amount: 6000.0},
+			{Step: "tax", Amount: 20000.0},
+		}
+
+		assert.Equal(t, 20000.0, result.Tax, "Tax should be 20000.0")
+		assert.Equal(t, expectedTrace, result.Trace[len(result.Trace)-3:], "Wrong trace")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("Refund", func(t *testing.T) {
+		result, err := CalculateTaxDetail(300000.0, 0.0, nil)
+		assert.Nil(t, err, "Should not be error")
+
+		result, err = CreditHalfYearTax(result, 10000.0)
+
+		assert.Equal(t, -1000.0, result.Tax, "Tax should be -1000.0")
+		assert.Equal(t, Step{Step: "tax refund", Amount: 1000.0}, result.Trace[len(result.Trace)-1])
+		assert.Nil(t, err, "Should not be error")
+	})
+}
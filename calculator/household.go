@@ -0,0 +1,231 @@
+package calculator
+
+import "github.com/TonRat/assessment-tax/apperror"
+
+type Spouse struct {
+	Incomes    []Income    `json:"incomes"`
+	WHT        float64     `json:"wht"`
+	Allowances []Allowance `json:"allowances"`
+}
+
+type TaxReturn struct {
+	Filer         string         `json:"filer"`
+	Incomes       []IncomeDetail `json:"incomes"`
+	TaxableIncome float64        `json:"taxableIncome"`
+	Tax           float64        `json:"tax"`
+}
+
+type FilingOption struct {
+	Filing  string      `json:"filing"`
+	Returns []TaxReturn `json:"returns"`
+	Tax     float64     `json:"tax"`
+}
+
+type HouseholdResult struct {
+	Options  []FilingOption `json:"options"`
+	Cheapest string         `json:"cheapest"`
+}
+
+// CalculateHousehold compares the filing options of a married couple:
+//   - separate: each spouse files their own income, and a spouse without
+//     income gives the other the spouse allowance
+//   - joint: spouse1 files all income of both with the spouse allowance
+//   - jointExceptSpouse1Salary / jointExceptSpouse2Salary: one spouse files
+//     their 40(1) income alone and the other files everything else
+//
+// Allowances of both spouses on the same return are added up by type and
+// capped once, while each spouse deducts the expense of their own income
+// with their own caps. The tax of each option is the household total after
+// wht.
+func CalculateHousehold(spouse1, spouse2 Spouse) (HouseholdResult, error) {
+	wht := spouse1.WHT + spouse2.WHT
+
+	separate, err := fileSeparately(spouse1, spouse2)
+	if err != nil {
+		return HouseholdResult{}, err
+	}
+	options := []FilingOption{separate}
+
+	joint, err := fileReturns("joint", wht, taxReturn{
+		filer:      "spouse1",
+		incomes:    []spouseIncome{{spouse: "spouse1", incomes: spouse1.Incomes}, {spouse: "spouse2", incomes: spouse2.Incomes}},
+		allowances: combineAllowances(spouse1.Allowances, spouse2.Allowances, Allowance{AllowanceType: "spouse", Amount: spouseAllowanceMax}),
+	})
+	if err != nil {
+		return HouseholdResult{}, err
+	}
+	options = append(options, joint)
+
+	for _, filing := range []struct {
+		name           string
+		salary, others Spouse
+		salaryFiler    string
+		othersFiler    string
+	}{
+		{name: "jointExceptSpouse1Salary", salary: spouse1, others: spouse2, salaryFiler: "spouse1", othersFiler: "spouse2"},
+		{name: "jointExceptSpouse2Salary", salary: spouse2, others: spouse1, salaryFiler: "spouse2", othersFiler: "spouse1"},
+	} {
+		salary, others := splitSalary(filing.salary.Incomes)
+		if len(salary) == 0 {
+			continue
+		}
+		option, err := fileReturns(filing.name, wht,
+			taxReturn{filer: filing.salaryFiler, incomes: []spouseIncome{{spouse: filing.salaryFiler, incomes: salary}}},
+			taxReturn{
+				filer:      filing.othersFiler,
+				incomes:    []spouseIncome{{spouse: filing.othersFiler, incomes: filing.others.Incomes}, {spouse: filing.salaryFiler, incomes: others}},
+				allowances: combineAllowances(filing.others.Allowances, filing.salary.Allowances),
+			},
+		)
+		if err != nil {
+			return HouseholdResult{}, err
+		}
+		options = append(options, option)
+	}
+
+	result := HouseholdResult{Options: options, Cheapest: options[0].Filing}
+	cheapestTax := options[0].Tax
+	for _, option := range options[1:] {
+		if option.Tax < cheapestTax {
+			result.Cheapest = option.Filing
+			cheapestTax = option.Tax
+		}
+	}
+	return result, nil
+}
+
+type taxReturn struct {
+	filer      string
+	incomes    []spouseIncome
+	allowances []Allowance
+}
+
+// spouseIncome is the income of one spouse on a return.
+type spouseIncome struct {
+	spouse  string
+	incomes []Income
+}
+
+func fileSeparately(spouse1, spouse2 Spouse) (FilingOption, error) {
+	option := FilingOption{Filing: "separate", Returns: []TaxReturn{}}
+	spouses := []struct {
+		filer        string
+		spouse       Spouse
+		spouseIncome []Income
+	}{
+		{filer: "spouse1", spouse: spouse1, spouseIncome: spouse2.Incomes},
+		{filer: "spouse2", spouse: spouse2, spouseIncome: spouse1.Incomes},
+	}
+	for _, s := range spouses {
+		if len(s.spouse.Incomes) == 0 {
+			if s.spouse.WHT != 0 {
+				return FilingOption{}, apperror.New(apperror.OutOfRange, s.filer+".wht", "wht must be between 0 and totalIncome")
+			}
+			continue
+		}
+		allowances := s.spouse.Allowances
+		if len(s.spouseIncome) == 0 {
+			allowances = combineAllowances(allowances, nil, Allowance{AllowanceType: "spouse", Amount: spouseAllowanceMax})
+		}
+
+		result, err := CalculateTaxFromIncomes(s.spouse.Incomes, nil, s.spouse.WHT, allowances)
+		if err != nil {
+			return FilingOption{}, apperror.Prefix(s.filer, err)
+		}
+		option.Returns = append(option.Returns, TaxReturn{
+			Filer:         s.filer,
+			Incomes:       result.Incomes,
+			TaxableIncome: result.TaxableIncome,
+			Tax:           result.Tax + s.spouse.WHT,
+		})
+		option.Tax += result.Tax
+	}
+	return option, nil
+}
+
+// fileReturns calculates each return before wht and credits the wht of the
+// household against the total.
+func fileReturns(filing string, wht float64, returns ...taxReturn) (FilingOption, error) {
+	option := FilingOption{Filing: filing, Returns: []TaxReturn{}}
+	for _, r := range returns {
+		grossIncome, netIncome, details, err := combineNetIncomes(r.incomes)
+		if err != nil {
+			return FilingOption{}, err
+		}
+		result, err := assessIncome(grossIncome, netIncome, details, r.allowances, 1.0)
+		if err != nil {
+			return FilingOption{}, apperror.Prefix(r.filer, err)
+		}
+		option.Returns = append(option.Returns, TaxReturn{
+			Filer:         r.filer,
+			Incomes:       result.Incomes,
+			TaxableIncome: result.TaxableIncome,
+			Tax:           result.Tax,
+		})
+		option.Tax += result.Tax
+	}
+	option.Tax -= wht
+	return option, nil
+}
+
+// combineNetIncomes deducts the expense of each spouse separately, so each
+// has their own expense caps, and adds up the incomes by category.
+func combineNetIncomes(incomes []spouseIncome) (float64, float64, []IncomeDetail, error) {
+	grossIncome := 0.0
+	netIncome := 0.0
+	byType := map[string]IncomeDetail{}
+	for _, income := range incomes {
+		gross, net, details, err := CalculateNetIncome(income.incomes)
+		if err != nil {
+			return 0.0, 0.0, nil, apperror.Prefix(income.spouse, err)
+		}
+		grossIncome += gross
+		netIncome += net
+		for _, detail := range details {
+			combined := byType[detail.IncomeType]
+			combined.IncomeType = detail.IncomeType
+			combined.Amount += detail.Amount
+			combined.Expense += detail.Expense
+			combined.NetIncome += detail.NetIncome
+			byType[detail.IncomeType] = combined
+		}
+	}
+
+	details := []IncomeDetail{}
+	for _, incomeType := range incomeTypes {
+		if detail, ok := byType[incomeType]; ok {
+			details = append(details, detail)
+		}
+	}
+	return grossIncome, netIncome, details, nil
+}
+
+func splitSalary(incomes []Income) ([]Income, []Income) {
+	salary := []Income{}
+	others := []Income{}
+	for _, income := range incomes {
+		if income.IncomeType == "40(1)" {
+			salary = append(salary, income)
+		} else {
+			others = append(others, income)
+		}
+	}
+	return salary, others
+}
+
+// combineAllowances adds up the allowances of two spouses by type.
+func combineAllowances(a, b []Allowance, extra ...Allowance) []Allowance {
+	combined := []Allowance{}
+	index := map[string]int{}
+	for _, allowances := range [][]Allowance{a, b, extra} {
+		for _, allowance := range allowances {
+			if i, ok := index[allowance.AllowanceType]; ok {
+				combined[i].Amount += allowance.Amount
+				continue
+			}
+			index[allowance.AllowanceType] = len(combined)
+			combined = append(combined, allowance)
+		}
+	}
+	return combined
+}
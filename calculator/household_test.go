@@ -0,0 +1,96 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateHousehold(t *testing.T) {
+	t.Run("BothHaveSalary", func(t *testing.T) {
+		spouse1 := Spouse{Incomes: []Income{{IncomeType: "40(1)", Amount: 1200000.0}}, WHT: 100000.0}
+		spouse2 := Spouse{Incomes: []Income{{IncomeType: "40(1)", Amount: 300000.0}}}
+
+		result, err := CalculateHousehold(spouse1, spouse2)
+
+		assert.Nil(t, err, "Should not be error")
+		assert.Len(t, result.Options, 4, "Should be 4 filing options")
+
+		separate := result.Options[0]
+		assert.Equal(t, "separate", separate.Filing)
+		assert.Equal(t, 18000.0, separate.Tax, "Separate tax should be 18000.0")
+		assert.Equal(t, TaxReturn{
+			Filer:         "spouse1",
+			Incomes:       []IncomeDetail{{IncomeType: "40(1)", Amount: 1200000.0, Expense: 100000.0, NetIncome: 1100000.0}},
+			TaxableIncome: 1040000.0,
+			Tax:           118000.0,
+		}, separate.Returns[0])
+
+		// 1,500,000 - 200,000 (expense of each spouse) - 60,000 (personal) - 60,000 (spouse) = 1,180,000
+		joint := result.Options[1]
+		assert.Equal(t, "joint", joint.Filing)
+		assert.Equal(t, 46000.0, joint.Tax, "Joint tax should be 46000.0")
+		assert.Len(t, joint.Returns, 1, "Joint should be 1 return")
+		assert.Equal(t, []IncomeDetail{{IncomeType: "40(1)", Amount: 1500000.0, Expense: 200000.0, NetIncome: 1300000.0}}, joint.Returns[0].Incomes)
+
+		assert.Equal(t, "jointExceptSpouse1Salary", result.Options[2].Filing)
+		assert.Equal(t, "jointExceptSpouse2Salary", result.Options[3].Filing)
+		assert.Equal(t, 18000.0, result.Options[3].Tax, "Tax should be 18000.0")
+
+		assert.Equal(t, "separate", result.Cheapest)
+	})
+
+	t.Run("BothSalaried", func(t *testing.T) {
+		spouse1 := Spouse{Incomes: []Income{{IncomeType: "40(1)", Amount: 1000000.0}}}
+		spouse2 := Spouse{Incomes: []Income{{IncomeType: "40(1)", Amount: 1000000.0}}}
+
+		result, err := CalculateHousehold(spouse1, spouse2)
+
+		assert.Nil(t, err, "Should not be error")
+		// 1,000,000 - 100,000 (expense) - 60,000 (personal) = 840,000 each
+		assert.Equal(t, 172000.0, result.Options[0].Tax, "Separate tax should be 172000.0")
+		// 2,000,000 - 200,000 (expense of each spouse) - 60,000 (personal) - 60,000 (spouse) = 1,680,000
+		assert.Equal(t, 246000.0, result.Options[1].Tax, "Joint tax should be 246000.0")
+		// 900,000 - 60,000 (personal) = 840,000 for each return
+		assert.Equal(t, 172000.0, result.Options[2].Tax, "Tax should be 172000.0")
+		assert.Equal(t, "separate", result.Cheapest)
+	})
+
+	t.Run("SpouseWithoutIncome", func(t *testing.T) {
+		spouse1 := Spouse{Incomes: []Income{{IncomeType: "40(1)", Amount: 1200000.0}}}
+		spouse2 := Spouse{Allowances: []Allowance{{AllowanceType: "donation", Amount: 10000.0}}}
+
+		result, err := CalculateHousehold(spouse1, spouse2)
+
+		assert.Nil(t, err, "Should not be error")
+		assert.Len(t, result.Options, 3, "Should be 3 filing options")
+		// 1,200,000 - 100,000 (expense) - 60,000 (personal) - 60,000 (spouse) = 980,000
+		assert.Equal(t, 107000.0, result.Options[0].Tax, "Separate tax should be 107000.0")
+		// spouse2 donation can only be used on a joint return
+		assert.Equal(t, 105500.0, result.Options[1].Tax, "Joint tax should be 105500.0")
+		assert.Equal(t, "joint", result.Cheapest)
+	})
+
+	t.Run("WhtWithoutIncome", func(t *testing.T) {
+		spouse1 := Spouse{Incomes: []Income{{IncomeType: "40(1)", Amount: 1200000.0}}}
+		spouse2 := Spouse{WHT: 1000.0}
+
+		_, err := CalculateHousehold(spouse1, spouse2)
+
+		assert.Equal(t, "wht must be between 0 and totalIncome", err.Error(), "Should be error")
+	})
+}
+
+func TestCombineAllowances(t *testing.T) {
+	a := []Allowance{{AllowanceType: "donation", Amount: 20000.0}}
+	b := []Allowance{{AllowanceType: "k-receipt", Amount: 5000.0}, {AllowanceType: "donation", Amount: 30000.0}}
+
+	combined := combineAllowances(a, b, Allowance{AllowanceType: "spouse", Amount: 60000.0})
+
+	expected := []Allowance{
+		{AllowanceType: "donation", Amount: 50000.0},
+		{AllowanceType: "k-receipt", Amount: 5000.0},
+		{AllowanceType: "spouse", Amount: 60000.0},
+	}
+	assert.Equal(t, expected, combined)
+}
@@ -0,0 +1,265 @@
+package calculator
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"strconv"
+)
+
+type Income struct {
+	IncomeType    string  `json:"incomeType" required:"true"`
+	Amount        float64 `json:"amount" required:"true"`
+	ActualExpense float64 `json:"actualExpense,omitempty"`
+}
+
+type IncomeDetail struct {
+	IncomeType string  `json:"incomeType"`
+	Amount     float64 `json:"amount"`
+	Expense    float64 `json:"expense"`
+	NetIncome  float64 `json:"netIncome"`
+}
+
+type IncomeTaxResult struct {
+	Tax           float64
+	TaxLevels     []TaxLevel
+	GrossIncome   float64
+	NetIncome     float64
+	TaxableIncome float64
+	TaxRate       TaxRate
+	Incomes       []IncomeDetail
+	TaxMethod     TaxMethod
+	Investment    *InvestmentElection
+	Trace         []Step
+}
+
+type TaxMethod struct {
+	ProgressiveTax float64 `json:"progressiveTax"`
+	AlternativeTax float64 `json:"alternativeTax"`
+	Applied        string  `json:"applied"`
+	Explanation    string  `json:"explanation"`
+}
+
+// expenseRule is the statutory expense deduction of an income category.
+// Categories sharing a group share the same max.
+type expenseRule struct {
+	rate          float64
+	max           float64
+	group         string
+	actualExpense bool
+}
+
+var (
+	alternativeTaxRate      = 0.005
+	alternativeTaxThreshold = 120000.0
+	alternativeTaxExemption = 5000.0
+)
+
+var incomeTypes = []string{"40(1)", "40(2)", "40(3)", "40(4)", "40(5)", "40(6)", "40(7)", "40(8)"}
+
+var expenseRules = map[string]expenseRule{
+	"40(1)": {rate: 0.5, max: 100000.0, group: "40(1)-40(2)"},
+	"40(2)": {rate: 0.5, max: 100000.0, group: "40(1)-40(2)"},
+	"40(3)": {rate: 0.5, max: 100000.0, group: "40(3)"},
+	"40(4)": {rate: 0.0},
+	"40(5)": {rate: 0.3, actualExpense: true},
+	"40(6)": {rate: 0.3, actualExpense: true},
+	"40(7)": {rate: 0.6, actualExpense: true},
+	"40(8)": {rate: 0.6, actualExpense: true},
+}
+
+// CalculateNetIncome sums the incomes per category and deducts the statutory
+// expense of each category. Categories 40(5)-40(8) may claim actual expense
+// instead of the flat rate.
+func CalculateNetIncome(incomes []Income) (float64, float64, []IncomeDetail, error) {
+	grossIncome, netIncome, details, _, err := deductExpenses(incomes)
+	return grossIncome, netIncome, details, err
+}
+
+func deductExpenses(incomes []Income) (float64, float64, []IncomeDetail, []Step, error) {
+	amounts := map[string]float64{}
+	actualExpenses := map[string]float64{}
+	for i, income := range incomes {
+		rule, ok := expenseRules[income.IncomeType]
+		if !ok {
+			return 0.0, 0.0, nil, nil, apperror.New(apperror.UnknownType, apperror.Index("incomes", i, "incomeType"), "incomeType must be one of 40(1) to 40(8)")
+		}
+		if income.Amount < 0 {
+			return 0.0, 0.0, nil, nil, apperror.New(apperror.OutOfRange, apperror.Index("incomes", i, "amount"), "income amount must be greater than or equal to 0")
+		}
+		if income.ActualExpense < 0 || income.ActualExpense > income.Amount {
+			return 0.0, 0.0, nil, nil, apperror.New(apperror.OutOfRange, apperror.Index("incomes", i, "actualExpense"), "actualExpense must be between 0 and amount")
+		}
+		if income.ActualExpense > 0 && !rule.actualExpense {
+			return 0.0, 0.0, nil, nil, apperror.New(apperror.ConflictingFields, apperror.Index("incomes", i, "actualExpense"), "actualExpense is only allowed for income 40(5) to 40(8)")
+		}
+		amounts[income.IncomeType] += income.Amount
+		actualExpenses[income.IncomeType] += income.ActualExpense
+	}
+
+	grossIncome := 0.0
+	netIncome := 0.0
+	used := map[string]float64{}
+	details := []IncomeDetail{}
+	trace := []Step{}
+	for _, incomeType := range incomeTypes {
+		amount, ok := amounts[incomeType]
+		if !ok {
+			continue
+		}
+		rule := expenseRules[incomeType]
+
+		expense := amount * rule.rate
+		step := Step{Step: "expense " + incomeType, Base: amount, Rate: rule.rate, Cap: rule.max, Note: "flat rate"}
+		if actualExpenses[incomeType] > 0 {
+			expense = actualExpenses[incomeType]
+			step = Step{Step: "expense " + incomeType, Requested: expense, Note: "actual expense"}
+		}
+		if rule.max > 0 && used[rule.group]+expense > rule.max {
+			step.Requested = expense
+			expense = rule.max - used[rule.group]
+			step.Note = "clipped by " + rule.group + " expense cap"
+		}
+		used[rule.group] += expense
+		step.Amount = expense
+		trace = append(trace, step)
+
+		grossIncome += amount
+		netIncome += amount - expense
+		details = append(details, IncomeDetail{
+			IncomeType: incomeType,
+			Amount:     amount,
+			Expense:    expense,
+			NetIncome:  amount - expense,
+		})
+	}
+
+	trace = append([]Step{{Step: "gross income", Amount: grossIncome}}, trace...)
+	trace = append(trace, Step{Step: "net income", Amount: netIncome})
+	return grossIncome, netIncome, details, trace, nil
+}
+
+// CalculateTaxFromIncomes calculates tax from incomes typed by category. wht
+// is checked against the gross income while the tax rate is applied to the
+// income left after expense deduction. Investment income is either left to
+// its final withholding or included in the assessment, whichever is cheaper.
+func CalculateTaxFromIncomes(incomes []Income, investments []Investment, wht float64, allowances []Allowance) (IncomeTaxResult, error) {
+	grossIncome, netIncome, details, incomeTrace, err := deductExpenses(incomes)
+	if err != nil {
+		return IncomeTaxResult{}, err
+	}
+	investmentIncome, err := sumInvestments(investments)
+	if err != nil {
+		return IncomeTaxResult{}, err
+	}
+	if wht < 0.0 || wht > grossIncome+investmentIncome {
+		return IncomeTaxResult{}, apperror.New(apperror.OutOfRange, "wht", "wht must be between 0 and totalIncome")
+	}
+
+	result, err := assessIncome(grossIncome, netIncome, details, allowances, 1.0)
+	if err != nil {
+		return IncomeTaxResult{}, err
+	}
+
+	if len(investments) > 0 {
+		result, err = electInvestmentOption(result, investments, allowances)
+		if err != nil {
+			return IncomeTaxResult{}, err
+		}
+	}
+
+	result.Trace = append(incomeTrace, result.Trace...)
+	result.Trace = append(result.Trace, creditWht(result.Tax, wht)...)
+	result.Tax -= wht
+	return result, nil
+}
+
+// assessIncome calculates the tax due on net income before any credit, using
+// the higher of the progressive and the alternative method.
+func assessIncome(grossIncome, netIncome float64, details []IncomeDetail, allowances []Allowance, limitRatio float64) (IncomeTaxResult, error) {
+	result, err := calculateTax(netIncome, allowances, limitRatio)
+	if err != nil {
+		return IncomeTaxResult{}, err
+	}
+	progressiveTax := result.Tax
+	trace := result.Trace
+
+	taxMethod := calculateTaxMethod(progressiveTax, details, alternativeTaxThreshold*limitRatio)
+	tax := progressiveTax
+	if taxMethod.Applied == "alternative" {
+		tax = taxMethod.AlternativeTax
+	}
+	if taxMethod.AlternativeTax > 0 {
+		trace = append(trace, Step{
+			Step:   "alternative tax",
+			Amount: taxMethod.AlternativeTax,
+			Base:   sumOtherIncome(details),
+			Rate:   alternativeTaxRate,
+			Note:   taxMethod.Explanation,
+		})
+	}
+
+	result.Tax = tax
+	result.GrossIncome = grossIncome
+	result.NetIncome = netIncome
+	result.TaxRate = CalculateTaxRate(grossIncome, result.TaxableIncome, tax)
+	result.Incomes = details
+	result.TaxMethod = taxMethod
+	result.Trace = trace
+	return result, nil
+}
+
+// CalculateTaxMethod compares the progressive tax with the alternative method
+// of 0.5% of gross income other than 40(1). The alternative method only
+// applies when that income is at least 120,000 and its tax is over 5,000.
+func CalculateTaxMethod(progressiveTax float64, details []IncomeDetail) TaxMethod {
+	return calculateTaxMethod(progressiveTax, details, alternativeTaxThreshold)
+}
+
+func calculateTaxMethod(progressiveTax float64, details []IncomeDetail, threshold float64) TaxMethod {
+	otherIncome := sumOtherIncome(details)
+
+	if otherIncome < threshold {
+		return TaxMethod{
+			ProgressiveTax: progressiveTax,
+			Applied:        "progressive",
+			Explanation:    "income other than 40(1) is less than " + formatAmount(threshold) + ", alternative method not applicable",
+		}
+	}
+
+	alternativeTax := otherIncome * alternativeTaxRate
+	method := TaxMethod{ProgressiveTax: progressiveTax, AlternativeTax: alternativeTax}
+	switch {
+	case alternativeTax <= alternativeTaxExemption:
+		method.Applied = "progressive"
+		method.Explanation = "alternative tax is not more than 5,000 and is exempted"
+	case alternativeTax > progressiveTax:
+		method.Applied = "alternative"
+		method.Explanation = "0.5% of income other than 40(1) is more than progressive tax"
+	default:
+		method.Applied = "progressive"
+		method.Explanation = "progressive tax is more than or equal to 0.5% of income other than 40(1)"
+	}
+	return method
+}
+
+func sumOtherIncome(details []IncomeDetail) float64 {
+	otherIncome := 0.0
+	for _, detail := range details {
+		if detail.IncomeType != "40(1)" {
+			otherIncome += detail.Amount
+		}
+	}
+	return otherIncome
+}
+
+// formatAmount formats a whole amount with thousands separators, e.g. 120,000.
+func formatAmount(amount float64) string {
+	digits := strconv.FormatFloat(amount, 'f', 0, 64)
+	formatted := ""
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			formatted += ","
+		}
+		formatted += string(digit)
+	}
+	return formatted
+}
@@ -0,0 +1,157 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TonRat/assessment-tax/apperror"
+)
+
+func TestCalculateNetIncome(t *testing.T) {
+	t.Run("SalaryAndFreelanceShareMax", func(t *testing.T) {
+		incomes := []Income{
+			{IncomeType: "40(1)", Amount: 150000.0},
+			{IncomeType: "40(2)", Amount: 100000.0},
+		}
+
+		grossIncome, netIncome, details, err := CalculateNetIncome(incomes)
+
+		expectedDetails := []IncomeDetail{
+			{IncomeType: "40(1)", Amount: 150000.0, Expense: 75000.0, NetIncome: 75000.0},
+			{IncomeType: "40(2)", Amount: 100000.0, Expense: 25000.0, NetIncome: 75000.0},
+		}
+
+		assert.Equal(t, 250000.0, grossIncome, "Wrong gross income")
+		assert.Equal(t, 150000.0, netIncome, "Wrong net income")
+		assert.Equal(t, expectedDetails, details, "Wrong income detail")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("FlatRateAndActualExpense", func(t *testing.T) {
+		incomes := []Income{
+			{IncomeType: "40(8)", Amount: 200000.0},
+			{IncomeType: "40(5)", Amount: 100000.0, ActualExpense: 40000.0},
+			{IncomeType: "40(4)", Amount: 10000.0},
+		}
+
+		grossIncome, netIncome, details, err := CalculateNetIncome(incomes)
+
+		expectedDetails := []IncomeDetail{
+			{IncomeType: "40(4)", Amount: 10000.0, Expense: 0.0, NetIncome: 10000.0},
+			{IncomeType: "40(5)", Amount: 100000.0, Expense: 40000.0, NetIncome: 60000.0},
+			{IncomeType: "40(8)", Amount: 200000.0, Expense: 120000.0, NetIncome: 80000.0},
+		}
+
+		assert.Equal(t, 310000.0, grossIncome, "Wrong gross income")
+		assert.Equal(t, 150000.0, netIncome, "Wrong net income")
+		assert.Equal(t, expectedDetails, details, "Wrong income detail")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("UnknownIncomeType", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "salary", Amount: 100000.0}}
+
+		_, _, _, err := CalculateNetIncome(incomes)
+
+		assert.Equal(t, "incomeType must be one of 40(1) to 40(8)", err.Error(), "Should be error")
+	})
+
+	t.Run("UnknownIncomeTypeField", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(1)", Amount: 100000.0}, {IncomeType: "salary", Amount: 100000.0}}
+
+		_, _, _, err := CalculateNetIncome(incomes)
+
+		expected := apperror.New(apperror.UnknownType, "incomes[1].incomeType", "incomeType must be one of 40(1) to 40(8)")
+		assert.Equal(t, expected, err, "Should be typed error")
+	})
+
+	t.Run("ActualExpenseNotAllowed", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(1)", Amount: 100000.0, ActualExpense: 10000.0}}
+
+		_, _, _, err := CalculateNetIncome(incomes)
+
+		assert.Equal(t, "actualExpense is only allowed for income 40(5) to 40(8)", err.Error(), "Should be error")
+	})
+}
+
+func TestCalculateTaxFromIncomes(t *testing.T) {
+	t.Run("SalaryOnly", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(1)", Amount: 600000.0}}
+		allowances := []Allowance{{AllowanceType: "donation", Amount: 0.0}}
+
+		result, err := CalculateTaxFromIncomes(incomes, nil, 0.0, allowances)
+
+		// 600,000 - 100,000 (expense) - 60,000 (personal deduction) = 440,000
+		assert.Equal(t, 29000.0, result.Tax, "Tax should be 29000.0")
+		assert.Equal(t, 600000.0, result.GrossIncome, "Wrong gross income")
+		assert.Equal(t, 500000.0, result.NetIncome, "Wrong net income")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("WhtCheckedAgainstGrossIncome", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(8)", Amount: 100000.0}}
+
+		result, err := CalculateTaxFromIncomes(incomes, nil, 50000.0, nil)
+
+		assert.Equal(t, -50000.0, result.Tax, "Tax should be -50000.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("WhtMoreThanGrossIncome", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(8)", Amount: 100000.0}}
+
+		_, err := CalculateTaxFromIncomes(incomes, nil, 100001.0, nil)
+
+		assert.Equal(t, "wht must be between 0 and totalIncome", err.Error(), "Should be error")
+	})
+}
+
+func TestCalculateTaxMethod(t *testing.T) {
+	t.Run("AlternativeApplied", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(8)", Amount: 2000000.0, ActualExpense: 1900000.0}}
+
+		result, err := CalculateTaxFromIncomes(incomes, nil, 0.0, nil)
+
+		expectedMethod := TaxMethod{
+			ProgressiveTax: 0.0,
+			AlternativeTax: 10000.0,
+			Applied:        "alternative",
+			Explanation:    "0.5% of income other than 40(1) is more than progressive tax",
+		}
+
+		assert.Equal(t, 10000.0, result.Tax, "Tax should be 10000.0")
+		assert.Equal(t, expectedMethod, result.TaxMethod, "Wrong tax method")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("ProgressiveMoreThanAlternative", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(8)", Amount: 2000000.0}}
+
+		result, err := CalculateTaxFromIncomes(incomes, nil, 0.0, nil)
+
+		// 2,000,000 - 1,200,000 (expense) - 60,000 (personal deduction) = 740,000
+		assert.Equal(t, 71000.0, result.Tax, "Tax should be 71000.0")
+		assert.Equal(t, 10000.0, result.TaxMethod.AlternativeTax, "Alternative tax should be 10000.0")
+		assert.Equal(t, "progressive", result.TaxMethod.Applied, "Progressive should be applied")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("AlternativeExempted", func(t *testing.T) {
+		details := []IncomeDetail{{IncomeType: "40(2)", Amount: 1000000.0}}
+
+		method := CalculateTaxMethod(0.0, details)
+
+		assert.Equal(t, 5000.0, method.AlternativeTax, "Alternative tax should be 5000.0")
+		assert.Equal(t, "progressive", method.Applied, "Progressive should be applied")
+	})
+
+	t.Run("SalaryOnlyNotApplicable", func(t *testing.T) {
+		details := []IncomeDetail{{IncomeType: "40(1)", Amount: 5000000.0}}
+
+		method := CalculateTaxMethod(1000.0, details)
+
+		assert.Equal(t, 0.0, method.AlternativeTax, "Alternative tax should be 0.0")
+		assert.Equal(t, "progressive", method.Applied, "Progressive should be applied")
+	})
+}
@@ -0,0 +1,137 @@
+package calculator
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"sort"
+)
+
+type Investment struct {
+	InvestmentType   string  `json:"investmentType" required:"true"`
+	Amount           float64 `json:"amount" required:"true"`
+	CorporateTaxRate float64 `json:"corporateTaxRate,omitempty"`
+}
+
+type InvestmentOption struct {
+	Tax            float64 `json:"tax"`
+	WithholdingTax float64 `json:"withholdingTax"`
+	DividendCredit float64 `json:"dividendCredit"`
+}
+
+type InvestmentElection struct {
+	FinalWithholding InvestmentOption `json:"finalWithholding"`
+	Include          InvestmentOption `json:"include"`
+	Cheaper          string           `json:"cheaper"`
+}
+
+var (
+	dividendWithholdingRate = 0.1
+	interestWithholdingRate = 0.15
+	defaultCorporateTaxRate = 0.2
+)
+
+func sumInvestments(investments []Investment) (float64, error) {
+	total := 0.0
+	for i, investment := range investments {
+		if investment.InvestmentType != "dividend" && investment.InvestmentType != "interest" {
+			return 0.0, apperror.New(apperror.UnknownType, apperror.Index("investments", i, "investmentType"), "investmentType must be dividend or interest")
+		}
+		if investment.Amount < 0 {
+			return 0.0, apperror.New(apperror.OutOfRange, apperror.Index("investments", i, "amount"), "investment amount must be greater than or equal to 0")
+		}
+		if investment.CorporateTaxRate < 0 || investment.CorporateTaxRate >= 1 {
+			return 0.0, apperror.New(apperror.OutOfRange, apperror.Index("investments", i, "corporateTaxRate"), "corporateTaxRate must be between 0 and 1")
+		}
+		total += investment.Amount
+	}
+	return total, nil
+}
+
+// withholdInvestments returns the tax withheld at source from investment
+// income, the dividend tax credit and the income grossed up by that credit.
+func withholdInvestments(investments []Investment) (float64, float64, float64) {
+	withholdingTax := 0.0
+	dividendCredit := 0.0
+	grossedUpIncome := 0.0
+	for _, investment := range investments {
+		switch investment.InvestmentType {
+		case "dividend":
+			corporateTaxRate := investment.CorporateTaxRate
+			if corporateTaxRate == 0 {
+				corporateTaxRate = defaultCorporateTaxRate
+			}
+			credit := investment.Amount * corporateTaxRate / (1 - corporateTaxRate)
+			withholdingTax += investment.Amount * dividendWithholdingRate
+			dividendCredit += credit
+			grossedUpIncome += investment.Amount + credit
+		case "interest":
+			withholdingTax += investment.Amount * interestWithholdingRate
+			grossedUpIncome += investment.Amount
+		}
+	}
+	return withholdingTax, dividendCredit, grossedUpIncome
+}
+
+// electInvestmentOption compares leaving investment income to its final
+// withholding with including it as 40(4) income, where the tax withheld and
+// the dividend credit are credited against the tax. The cheaper one is kept.
+func electInvestmentOption(result IncomeTaxResult, investments []Investment, allowances []Allowance) (IncomeTaxResult, error) {
+	withholdingTax, dividendCredit, grossedUpIncome := withholdInvestments(investments)
+
+	details := []IncomeDetail{}
+	included := false
+	for _, detail := range result.Incomes {
+		if detail.IncomeType == "40(4)" {
+			detail.Amount += grossedUpIncome
+			detail.NetIncome += grossedUpIncome
+			included = true
+		}
+		details = append(details, detail)
+	}
+	if !included {
+		details = append(details, IncomeDetail{IncomeType: "40(4)", Amount: grossedUpIncome, NetIncome: grossedUpIncome})
+		sortIncomeDetails(details)
+	}
+
+	includeResult, err := assessIncome(result.GrossIncome+grossedUpIncome, result.NetIncome+grossedUpIncome, details, allowances, 1.0)
+	if err != nil {
+		return IncomeTaxResult{}, err
+	}
+
+	election := InvestmentElection{
+		FinalWithholding: InvestmentOption{
+			Tax:            result.Tax + withholdingTax,
+			WithholdingTax: withholdingTax,
+		},
+		Include: InvestmentOption{
+			Tax:            includeResult.Tax - dividendCredit,
+			WithholdingTax: withholdingTax,
+			DividendCredit: dividendCredit,
+		},
+		Cheaper: "finalWithholding",
+	}
+
+	if election.Include.Tax < election.FinalWithholding.Tax {
+		election.Cheaper = "include"
+		result = includeResult
+		result.Tax -= withholdingTax + dividendCredit
+		result.Trace = append([]Step{{Step: "investment income 40(4)", Amount: grossedUpIncome, Note: "included in assessment, cheaper than final withholding"}}, result.Trace...)
+		result.Trace = append(result.Trace,
+			Step{Step: "investment withholding credit", Amount: withholdingTax},
+			Step{Step: "dividend credit", Amount: dividendCredit},
+		)
+	} else {
+		result.Trace = append(result.Trace, Step{Step: "investment final withholding", Amount: withholdingTax, Note: "not included in assessment, cheaper than including"})
+	}
+	result.Investment = &election
+	return result, nil
+}
+
+func sortIncomeDetails(details []IncomeDetail) {
+	order := map[string]int{}
+	for i, incomeType := range incomeTypes {
+		order[incomeType] = i
+	}
+	sort.SliceStable(details, func(i, j int) bool {
+		return order[details[i].IncomeType] < order[details[j].IncomeType]
+	})
+}
@@ -0,0 +1,51 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateTaxWithInvestments(t *testing.T) {
+	t.Run("IncludeDividendIsCheaper", func(t *testing.T) {
+		investments := []Investment{{InvestmentType: "dividend", Amount: 100000.0, CorporateTaxRate: 0.2}}
+
+		result, err := CalculateTaxFromIncomes(nil, investments, 0.0, nil)
+
+		expectedElection := &InvestmentElection{
+			FinalWithholding: InvestmentOption{Tax: 10000.0, WithholdingTax: 10000.0},
+			Include:          InvestmentOption{Tax: -25000.0, WithholdingTax: 10000.0, DividendCredit: 25000.0},
+			Cheaper:          "include",
+		}
+		expectedIncomes := []IncomeDetail{
+			{IncomeType: "40(4)", Amount: 125000.0, NetIncome: 125000.0},
+		}
+
+		// 10,000 withheld and 25,000 dividend credit are refunded
+		assert.Equal(t, -35000.0, result.Tax, "Tax should be -35000.0")
+		assert.Equal(t, expectedElection, result.Investment, "Wrong investment election")
+		assert.Equal(t, expectedIncomes, result.Incomes, "Wrong income detail")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("FinalWithholdingIsCheaper", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(1)", Amount: 5000000.0}}
+		investments := []Investment{{InvestmentType: "interest", Amount: 100000.0}}
+
+		result, err := CalculateTaxFromIncomes(incomes, investments, 0.0, nil)
+
+		assert.Equal(t, 1304000.0, result.Tax, "Tax should be 1304000.0")
+		assert.Equal(t, 1319000.0, result.Investment.FinalWithholding.Tax, "Wrong final withholding tax")
+		assert.Equal(t, 1339000.0, result.Investment.Include.Tax, "Wrong include tax")
+		assert.Equal(t, "finalWithholding", result.Investment.Cheaper, "Final withholding should be cheaper")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("UnknownInvestmentType", func(t *testing.T) {
+		investments := []Investment{{InvestmentType: "bond", Amount: 100000.0}}
+
+		_, err := CalculateTaxFromIncomes(nil, investments, 0.0, nil)
+
+		assert.Equal(t, "investmentType must be dividend or interest", err.Error(), "Should be error")
+	})
+}
@@ -0,0 +1,52 @@
+package calculator
+
+import "github.com/TonRat/assessment-tax/i18n"
+
+// LocalizeTaxLevels renders the tax level labels in lang.
+func LocalizeTaxLevels(lang string, taxLevels []TaxLevel) []TaxLevel {
+	if taxLevels == nil {
+		return nil
+	}
+	localized := make([]TaxLevel, len(taxLevels))
+	for i, taxLevel := range taxLevels {
+		taxLevel.Level = i18n.Text(lang, taxLevel.Level)
+		localized[i] = taxLevel
+	}
+	return localized
+}
+
+// LocalizeTaxRate renders the labels of the current and next tax level in
+// lang.
+func LocalizeTaxRate(lang string, rate TaxRate) TaxRate {
+	rate.Level = i18n.Text(lang, rate.Level)
+	if rate.NextLevel != "" {
+		rate.NextLevel = i18n.Text(lang, rate.NextLevel)
+	}
+	return rate
+}
+
+// LocalizeSteps renders the explanation steps and their notes in lang.
+func LocalizeSteps(lang string, steps []Step) []Step {
+	if steps == nil {
+		return nil
+	}
+	localized := make([]Step, len(steps))
+	for i, step := range steps {
+		step.Step = i18n.Text(lang, step.Step)
+		if step.Note != "" {
+			step.Note = i18n.Text(lang, step.Note)
+		}
+		localized[i] = step
+	}
+	return localized
+}
+
+// LocalizeTaxMethod renders the explanation of the tax method in lang.
+func LocalizeTaxMethod(lang string, method *TaxMethod) *TaxMethod {
+	if method == nil {
+		return nil
+	}
+	localized := *method
+	localized.Explanation = i18n.Text(lang, method.Explanation)
+	return &localized
+}
@@ -0,0 +1,93 @@
+package calculator
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"math"
+	"time"
+)
+
+type Installment struct {
+	Number  int     `json:"number"`
+	DueDate string  `json:"dueDate"`
+	Amount  float64 `json:"amount"`
+}
+
+type Payment struct {
+	DueDate             string        `json:"dueDate"`
+	PaymentDate         string        `json:"paymentDate,omitempty"`
+	LateMonths          int           `json:"lateMonths"`
+	Surcharge           float64       `json:"surcharge"`
+	Total               float64       `json:"total"`
+	InstallmentEligible bool          `json:"installmentEligible"`
+	Installments        []Installment `json:"installments,omitempty"`
+}
+
+const dateLayout = "2006-01-02"
+
+var (
+	surchargeRate     = 0.015
+	installmentMinTax = 3000.0
+	installmentCount  = 3
+)
+
+// CalculatePayment works out the surcharge of 1.5% per month, or part of a
+// month, when tax is paid after the due date of the period, capped at the tax
+// itself. When installments are asked for and the tax is at least 3,000 it is
+// split into three monthly installments starting on the due date.
+func CalculatePayment(tax float64, period string, paymentDate string, installments bool) (Payment, error) {
+	if tax < 0 {
+		return Payment{}, apperror.New(apperror.OutOfRange, "tax", "tax must be greater than or equal to 0")
+	}
+
+	dueDate := taxDueDate(period)
+	payment := Payment{DueDate: dueDate.Format(dateLayout), Total: tax}
+	if paymentDate != "" {
+		paidOn, err := time.Parse(dateLayout, paymentDate)
+		if err != nil {
+			return Payment{}, apperror.New(apperror.InvalidFormat, "paymentDate", "paymentDate must be in YYYY-MM-DD format")
+		}
+		payment.PaymentDate = paymentDate
+		payment.LateMonths = lateMonths(dueDate, paidOn)
+		payment.Surcharge = math.Min(roundSatang(tax*surchargeRate*float64(payment.LateMonths)), tax)
+		payment.Total = tax + payment.Surcharge
+	}
+
+	payment.InstallmentEligible = tax >= installmentMinTax
+	if installments && payment.InstallmentEligible {
+		amount := roundSatang(tax / float64(installmentCount))
+		for i := 0; i < installmentCount; i++ {
+			installment := Installment{Number: i + 1, DueDate: addMonths(dueDate, i).Format(dateLayout), Amount: amount}
+			if i == installmentCount-1 {
+				installment.Amount = roundSatang(tax - amount*float64(installmentCount-1))
+			}
+			payment.Installments = append(payment.Installments, installment)
+		}
+	}
+	return payment, nil
+}
+
+// lateMonths counts the months, with a part of a month counted as a whole,
+// from the due date to the payment date.
+func lateMonths(dueDate, paidOn time.Time) int {
+	months := 0
+	for paidOn.After(addMonths(dueDate, months)) {
+		months++
+	}
+	return months
+}
+
+// addMonths adds months to date and keeps the day within the month, so
+// 31 March plus one month is 30 April.
+func addMonths(date time.Time, months int) time.Time {
+	firstDay := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstDay.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstDay.Year(), firstDay.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+func roundSatang(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
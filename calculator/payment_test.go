@@ -0,0 +1,97 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculatePayment(t *testing.T) {
+	t.Run("PaidOnTime", func(t *testing.T) {
+		payment, err := CalculatePayment(29000.0, "", "2025-03-31", false)
+
+		expectedPayment := Payment{
+			DueDate:             "2025-03-31",
+			PaymentDate:         "2025-03-31",
+			LateMonths:          0,
+			Surcharge:           0.0,
+			Total:               29000.0,
+			InstallmentEligible: true,
+		}
+
+		assert.Equal(t, expectedPayment, payment, "Wrong payment")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("PartOfMonthCountsAsMonth", func(t *testing.T) {
+		payment, err := CalculatePayment(29000.0, "", "2025-05-01", false)
+
+		assert.Equal(t, 2, payment.LateMonths, "Late months should be 2")
+		assert.Equal(t, 870.0, payment.Surcharge, "Surcharge should be 870.0")
+		assert.Equal(t, 29870.0, payment.Total, "Total should be 29870.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("EndOfShorterMonth", func(t *testing.T) {
+		payment, err := CalculatePayment(10000.0, "", "2025-04-30", false)
+
+		assert.Equal(t, 1, payment.LateMonths, "Late months should be 1")
+		assert.Equal(t, 150.0, payment.Surcharge, "Surcharge should be 150.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("SurchargeCappedAtTax", func(t *testing.T) {
+		payment, err := CalculatePayment(1000.0, "", "2031-01-01", false)
+
+		assert.Equal(t, 1000.0, payment.Surcharge, "Surcharge should be 1000.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("HalfYearDueDate", func(t *testing.T) {
+		payment, err := CalculatePayment(10000.0, "half-year", "2024-10-15", false)
+
+		assert.Equal(t, "2024-09-30", payment.DueDate, "Due date should be 2024-09-30")
+		assert.Equal(t, 1, payment.LateMonths, "Late months should be 1")
+		assert.Equal(t, 150.0, payment.Surcharge, "Surcharge should be 150.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("TaxYear", func(t *testing.T) {
+		taxYear := TaxYear
+		TaxYear = 2025
+		defer func() { TaxYear = taxYear }()
+
+		payment, err := CalculatePayment(10000.0, "", "2026-03-31", false)
+
+		assert.Equal(t, "2026-03-31", payment.DueDate, "Due date should be 2026-03-31")
+		assert.Equal(t, 0.0, payment.Surcharge, "Surcharge should be 0.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("Installments", func(t *testing.T) {
+		payment, err := CalculatePayment(10000.0, "", "", true)
+
+		expectedInstallments := []Installment{
+			{Number: 1, DueDate: "2025-03-31", Amount: 3333.33},
+			{Number: 2, DueDate: "2025-04-30", Amount: 3333.33},
+			{Number: 3, DueDate: "2025-05-31", Amount: 3333.34},
+		}
+
+		assert.Equal(t, expectedInstallments, payment.Installments, "Wrong installments")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("TaxTooLowForInstallments", func(t *testing.T) {
+		payment, err := CalculatePayment(2999.0, "", "", true)
+
+		assert.False(t, payment.InstallmentEligible, "Should not be eligible")
+		assert.Empty(t, payment.Installments, "Should be no installment")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("InvalidPaymentDate", func(t *testing.T) {
+		_, err := CalculatePayment(1000.0, "", "31/03/2025", false)
+
+		assert.Equal(t, "paymentDate must be in YYYY-MM-DD format", err.Error(), "Should be error")
+	})
+}
@@ -0,0 +1,39 @@
+package calculator
+
+import "math"
+
+type TaxRate struct {
+	MarginalRate  float64 `json:"marginalRate"`
+	EffectiveRate float64 `json:"effectiveRate"`
+	Level         string  `json:"level"`
+	NextLevel     string  `json:"nextLevel,omitempty"`
+	NextRate      float64 `json:"nextRate,omitempty"`
+	Headroom      float64 `json:"headroom"`
+}
+
+// CalculateTaxRate finds the tax level the next baht of taxable income falls
+// in, the income left before the next level and the tax paid per baht of
+// gross income. Income exactly on the upper bound of a level has used it up,
+// so it is in the level above. There is no next level or headroom in the top
+// level.
+func CalculateTaxRate(grossIncome, taxableIncome, tax float64) TaxRate {
+	taxRate := TaxRate{}
+	if grossIncome > 0 {
+		taxRate.EffectiveRate = tax / grossIncome
+	}
+
+	for i, bracket := range taxBrackets {
+		if taxableIncome >= bracket.upper {
+			continue
+		}
+		taxRate.MarginalRate = bracket.rate
+		taxRate.Level = bracket.level
+		if i+1 < len(taxBrackets) {
+			taxRate.NextLevel = taxBrackets[i+1].level
+			taxRate.NextRate = taxBrackets[i+1].rate
+			taxRate.Headroom = bracket.upper - math.Max(taxableIncome, 0)
+		}
+		break
+	}
+	return taxRate
+}
@@ -0,0 +1,81 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateTaxRate(t *testing.T) {
+	t.Run("FirstTaxedLevel", func(t *testing.T) {
+		result, err := CalculateTaxDetail(500000.0, 0.0, nil)
+
+		expectedTaxRate := TaxRate{
+			MarginalRate:  0.1,
+			EffectiveRate: 0.058,
+			Level:         "150,001-500,000",
+			NextLevel:     "500,001-1,000,000",
+			NextRate:      0.15,
+			Headroom:      60000.0,
+		}
+
+		assert.Equal(t, expectedTaxRate, result.TaxRate, "Wrong tax rate")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("BelowPersonalDeduction", func(t *testing.T) {
+		result, err := CalculateTaxDetail(50000.0, 0.0, nil)
+
+		expectedTaxRate := TaxRate{
+			MarginalRate:  0.0,
+			EffectiveRate: 0.0,
+			Level:         "0-150,000",
+			NextLevel:     "150,001-500,000",
+			NextRate:      0.1,
+			Headroom:      150000.0,
+		}
+
+		assert.Equal(t, expectedTaxRate, result.TaxRate, "Wrong tax rate")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("TopLevel", func(t *testing.T) {
+		taxRate := CalculateTaxRate(4000000.0, 3000000.0, 660000.0)
+
+		expectedTaxRate := TaxRate{
+			MarginalRate:  0.35,
+			EffectiveRate: 0.165,
+			Level:         "2,000,001 ขึ้นไป",
+		}
+
+		assert.Equal(t, expectedTaxRate, taxRate, "Wrong tax rate")
+	})
+
+	boundaries := []struct {
+		name          string
+		taxableIncome float64
+		expected      TaxRate
+	}{
+		{name: "On150000", taxableIncome: 150000.0, expected: TaxRate{MarginalRate: 0.1, Level: "150,001-500,000", NextLevel: "500,001-1,000,000", NextRate: 0.15, Headroom: 350000.0}},
+		{name: "On500000", taxableIncome: 500000.0, expected: TaxRate{MarginalRate: 0.15, Level: "500,001-1,000,000", NextLevel: "1,000,001-2,000,000", NextRate: 0.2, Headroom: 500000.0}},
+	}
+	for _, tt := range boundaries {
+		t.Run(tt.name, func(t *testing.T) {
+			taxRate := CalculateTaxRate(0.0, tt.taxableIncome, 0.0)
+
+			assert.Equal(t, tt.expected, taxRate, "Income on a boundary should be in the level above")
+		})
+	}
+
+	t.Run("FromIncomes", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(1)", Amount: 1000000.0}}
+
+		result, err := CalculateTaxFromIncomes(incomes, nil, 0.0, nil)
+
+		// 1,000,000 - 100,000 (expense) - 60,000 (personal deduction) = 840,000
+		assert.Equal(t, 0.15, result.TaxRate.MarginalRate, "Marginal rate should be 0.15")
+		assert.Equal(t, 160000.0, result.TaxRate.Headroom, "Headroom should be 160000.0")
+		assert.Equal(t, 0.086, result.TaxRate.EffectiveRate, "Effective rate should be 0.086")
+		assert.Nil(t, err, "Should not be error")
+	})
+}
@@ -0,0 +1,68 @@
+package calculator
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"math"
+)
+
+// SolveIncome finds the total income giving the target tax or the target net
+// income after tax, with the same allowances as CalculateTax. The progressive
+// rate is linear within each tax level so the income is solved exactly level
+// by level. A target tax of 0 gives the highest income that is still not
+// taxed.
+func SolveIncome(target string, amount float64, allowances []Allowance) (float64, error) {
+	if amount < 0 {
+		return 0.0, apperror.New(apperror.OutOfRange, "amount", "amount must be greater than or equal to 0")
+	}
+
+	deductions, err := deductAllowances(allowances, 1.0)
+	if err != nil {
+		return 0.0, err
+	}
+	deduction := 0.0
+	for _, step := range deductions {
+		deduction += step.Amount
+	}
+
+	switch target {
+	case "tax":
+		return solveIncomeForTax(amount, deduction), nil
+	case "net":
+		return solveIncomeForNet(amount, deduction), nil
+	default:
+		return 0.0, apperror.New(apperror.UnknownType, "target", "target must be tax or net")
+	}
+}
+
+func solveIncomeForTax(tax, deduction float64) float64 {
+	levelTax := 0.0
+	for _, bracket := range taxBrackets {
+		maxTax := (bracket.upper - bracket.lower) * bracket.rate
+		if bracket.rate == 0 {
+			if tax == 0 {
+				return bracket.upper + deduction
+			}
+			continue
+		}
+		if tax <= levelTax+maxTax {
+			return bracket.lower + (tax-levelTax)/bracket.rate + deduction
+		}
+		levelTax += maxTax
+	}
+	return 0.0
+}
+
+// solveIncomeForNet solves net = income - tax, where within a tax level
+// tax = levelTax + (income - deduction - lower) * rate.
+func solveIncomeForNet(net, deduction float64) float64 {
+	levelTax := 0.0
+	for _, bracket := range taxBrackets {
+		maxTax := (bracket.upper - bracket.lower) * bracket.rate
+		if math.IsInf(bracket.upper, 1) || net <= bracket.upper+deduction-levelTax-maxTax {
+			taxableIncome := (net - deduction + levelTax - bracket.lower*bracket.rate) / (1 - bracket.rate)
+			return taxableIncome + deduction
+		}
+		levelTax += maxTax
+	}
+	return 0.0
+}
@@ -0,0 +1,66 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolveIncome(t *testing.T) {
+	t.Run("TargetTax", func(t *testing.T) {
+		totalIncome, err := SolveIncome("tax", 29000.0, nil)
+
+		assert.Equal(t, 500000.0, totalIncome, "Total income should be 500000.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("TargetTaxWithAllowance", func(t *testing.T) {
+		allowances := []Allowance{
+			{AllowanceType: "k-receipt", Amount: 200000.0},
+			{AllowanceType: "donation", Amount: 100000.0},
+		}
+
+		totalIncome, err := SolveIncome("tax", 14000.0, allowances)
+
+		assert.Equal(t, 500000.0, totalIncome, "Total income should be 500000.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("TargetTaxIsZero", func(t *testing.T) {
+		totalIncome, err := SolveIncome("tax", 0.0, nil)
+
+		assert.Equal(t, 210000.0, totalIncome, "Total income should be 210000.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("TargetTaxInTopLevel", func(t *testing.T) {
+		totalIncome, err := SolveIncome("tax", 345000.0, nil)
+
+		assert.InDelta(t, 2160000.0, totalIncome, 0.000001, "Total income should be 2160000.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("TargetNet", func(t *testing.T) {
+		totalIncome, err := SolveIncome("net", 471000.0, nil)
+
+		assert.Equal(t, 500000.0, totalIncome, "Total income should be 500000.0")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("TargetNetMatchesCalculateTax", func(t *testing.T) {
+		for _, net := range []float64{100000.0, 800000.0, 1500000.0, 5000000.0} {
+			totalIncome, err := SolveIncome("net", net, nil)
+			assert.Nil(t, err, "Should not be error")
+
+			tax, _, err := CalculateTax(totalIncome, 0.0, nil)
+			assert.Nil(t, err, "Should not be error")
+			assert.InDelta(t, net, totalIncome-tax, 0.000001, "Net should be %.1f", net)
+		}
+	})
+
+	t.Run("UnknownTarget", func(t *testing.T) {
+		_, err := SolveIncome("gross", 1000.0, nil)
+
+		assert.Equal(t, "target must be tax or net", err.Error(), "Should be error")
+	})
+}
@@ -0,0 +1,66 @@
+package calculator
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"strings"
+	"unicode/utf8"
+)
+
+// Taxpayer links a calculation to a person. All fields are optional.
+type Taxpayer struct {
+	TaxpayerID string `json:"taxpayerId,omitempty"`
+	FirstName  string `json:"firstName,omitempty"`
+	LastName   string `json:"lastName,omitempty"`
+}
+
+var nameMaxLength = 100
+
+// ValidateTaxpayer checks the taxpayer ID and trims the names. The ID may be
+// written with dashes or spaces and is returned as 13 digits.
+func ValidateTaxpayer(taxpayer Taxpayer) (Taxpayer, error) {
+	if taxpayer.TaxpayerID != "" {
+		id, err := normalizeTaxID(taxpayer.TaxpayerID)
+		if err != nil {
+			return Taxpayer{}, err
+		}
+		taxpayer.TaxpayerID = id
+	}
+
+	for _, name := range []struct {
+		field string
+		value *string
+	}{
+		{field: "firstName", value: &taxpayer.FirstName},
+		{field: "lastName", value: &taxpayer.LastName},
+	} {
+		*name.value = strings.TrimSpace(*name.value)
+		if utf8.RuneCountInString(*name.value) > nameMaxLength {
+			return Taxpayer{}, apperror.New(apperror.OutOfRange, name.field, name.field+" must be at most 100 characters")
+		}
+	}
+	return taxpayer, nil
+}
+
+// normalizeTaxID validates a 13-digit Thai national ID or tax ID. The last
+// digit is a check digit: (11 - sum of the first 12 digits weighted 13 down
+// to 2, mod 11) mod 10.
+func normalizeTaxID(id string) (string, error) {
+	digits := strings.NewReplacer("-", "", " ", "").Replace(id)
+	if len(digits) != 13 {
+		return "", apperror.New(apperror.InvalidFormat, "taxpayerId", "taxpayerId must be 13 digits")
+	}
+
+	sum := 0
+	for i, r := range digits {
+		if r < '0' || r > '9' {
+			return "", apperror.New(apperror.InvalidFormat, "taxpayerId", "taxpayerId must be 13 digits")
+		}
+		if i < 12 {
+			sum += int(r-'0') * (13 - i)
+		}
+	}
+	if (11-sum%11)%10 != int(digits[12]-'0') {
+		return "", apperror.New(apperror.InvalidChecksum, "taxpayerId", "taxpayerId check digit is invalid")
+	}
+	return digits, nil
+}
@@ -0,0 +1,46 @@
+package calculator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TonRat/assessment-tax/apperror"
+)
+
+func TestValidateTaxpayer(t *testing.T) {
+	t.Run("ValidIDWithDashes", func(t *testing.T) {
+		taxpayer, err := ValidateTaxpayer(Taxpayer{TaxpayerID: "1-1017-00203-94-8", FirstName: " Somchai ", LastName: "Jaidee"})
+
+		assert.Equal(t, Taxpayer{TaxpayerID: "1101700203948", FirstName: "Somchai", LastName: "Jaidee"}, taxpayer, "Wrong taxpayer")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("NoTaxpayer", func(t *testing.T) {
+		taxpayer, err := ValidateTaxpayer(Taxpayer{})
+
+		assert.Equal(t, Taxpayer{}, taxpayer, "Wrong taxpayer")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("WrongCheckDigit", func(t *testing.T) {
+		_, err := ValidateTaxpayer(Taxpayer{TaxpayerID: "1101700203949"})
+
+		assert.Equal(t, apperror.New(apperror.InvalidChecksum, "taxpayerId", "taxpayerId check digit is invalid"), err, "Should be error")
+	})
+
+	t.Run("NotThirteenDigits", func(t *testing.T) {
+		for _, id := range []string{"110170020394", "11017002039480", "110170020394a"} {
+			_, err := ValidateTaxpayer(Taxpayer{TaxpayerID: id})
+
+			assert.Equal(t, apperror.New(apperror.InvalidFormat, "taxpayerId", "taxpayerId must be 13 digits"), err, "Should be error for "+id)
+		}
+	})
+
+	t.Run("NameTooLong", func(t *testing.T) {
+		_, err := ValidateTaxpayer(Taxpayer{FirstName: strings.Repeat("ก", 101)})
+
+		assert.Equal(t, apperror.New(apperror.OutOfRange, "firstName", "firstName must be at most 100 characters"), err, "Should be error")
+	})
+}
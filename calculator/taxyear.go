@@ -0,0 +1,22 @@
+package calculator
+
+import "time"
+
+// TaxYear is the year of the income assessed, in the Gregorian calendar, so
+// 2024 is tax year 2567. Remittance dates and due dates follow from it.
+var TaxYear = 2024
+
+// taxDueDate is the filing deadline of a period of TaxYear: 30 September of
+// the same year for the half-year return and 31 March of the next year for
+// the annual return.
+func taxDueDate(period string) time.Time {
+	if period == "half-year" {
+		return time.Date(TaxYear, time.September, 30, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(TaxYear+1, time.March, 31, 0, 0, 0, 0, time.UTC)
+}
+
+// thaiYear is year in the Buddhist era of the Thai calendar.
+func thaiYear(year int) int {
+	return year + 543
+}
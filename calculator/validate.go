@@ -0,0 +1,141 @@
+package calculator
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"math"
+	"strings"
+)
+
+// validAllowanceTypes are the allowance types CalculateTax deducts.
+var validAllowanceTypes = []string{"spouse", "k-receipt", "donation"}
+
+// ValidateTaxInput checks totalIncome, wht and allowances all at once and
+// returns every problem found, where CalculateTax stops at the first one.
+// wht is only compared with totalIncome when totalIncome is valid.
+func ValidateTaxInput(totalIncome, wht float64, allowances []Allowance) apperror.Errors {
+	errs := apperror.Errors{}
+	totalIncomeErr := validateAmount("totalIncome", totalIncome)
+	if totalIncomeErr != nil {
+		errs = append(errs, totalIncomeErr)
+	}
+	if math.IsNaN(wht) || math.IsInf(wht, 0) {
+		errs = append(errs, validateAmount("wht", wht))
+	} else if wht < 0 || (totalIncomeErr == nil && wht > totalIncome) {
+		errs = append(errs, apperror.New(apperror.OutOfRange, "wht", "wht must be between 0 and totalIncome"))
+	}
+	return append(errs, ValidateAllowances(allowances)...)
+}
+
+// ValidateIncomeInput checks incomes, investments, foreign incomes, wht and
+// allowances all at once, like ValidateTaxInput. wht is only compared with
+// the gross income when every income is valid and foreign incomes can be
+// converted to baht.
+func ValidateIncomeInput(incomes []Income, investments []Investment, foreignIncomes []ForeignIncome, wht float64, allowances []Allowance) apperror.Errors {
+	errs := apperror.Errors{}
+	errs = append(errs, ValidateIncomes(incomes)...)
+	errs = append(errs, ValidateInvestments(investments)...)
+	errs = append(errs, ValidateForeignIncomes(foreignIncomes)...)
+	grossIncome, ok := 0.0, len(errs) == 0
+	if ok {
+		grossIncome, ok = sumGrossIncome(incomes, investments, foreignIncomes)
+	}
+	if math.IsNaN(wht) || math.IsInf(wht, 0) {
+		errs = append(errs, validateAmount("wht", wht))
+	} else if wht < 0 || (ok && wht > grossIncome) {
+		errs = append(errs, apperror.New(apperror.OutOfRange, "wht", "wht must be between 0 and totalIncome"))
+	}
+	return append(errs, ValidateAllowances(allowances)...)
+}
+
+// ValidateIncomes reports income amounts that are negative, NaN or infinite.
+func ValidateIncomes(incomes []Income) apperror.Errors {
+	errs := apperror.Errors{}
+	for i, income := range incomes {
+		if err := validateAmount(apperror.Index("incomes", i, "amount"), income.Amount); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// ValidateInvestments reports investment amounts that are negative, NaN or
+// infinite.
+func ValidateInvestments(investments []Investment) apperror.Errors {
+	errs := apperror.Errors{}
+	for i, investment := range investments {
+		if err := validateAmount(apperror.Index("investments", i, "amount"), investment.Amount); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// ValidateForeignIncomes reports foreign income amounts that are negative,
+// NaN or infinite.
+func ValidateForeignIncomes(foreignIncomes []ForeignIncome) apperror.Errors {
+	errs := apperror.Errors{}
+	for i, foreign := range foreignIncomes {
+		if err := validateAmount(apperror.Index("foreignIncomes", i, "amount"), foreign.Amount); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// sumGrossIncome is the income wht is withheld from, with foreign incomes in
+// baht. It is false when foreign incomes cannot be converted.
+func sumGrossIncome(incomes []Income, investments []Investment, foreignIncomes []ForeignIncome) (float64, bool) {
+	converted, _, err := ConvertForeignIncomes(foreignIncomes)
+	if err != nil {
+		return 0.0, false
+	}
+	total := 0.0
+	for _, income := range append(append([]Income{}, incomes...), converted...) {
+		total += income.Amount
+	}
+	for _, investment := range investments {
+		total += investment.Amount
+	}
+	return total, true
+}
+
+// ValidateAllowances reports unknown and duplicate allowance types and
+// amounts that are negative, NaN or infinite.
+func ValidateAllowances(allowances []Allowance) apperror.Errors {
+	errs := apperror.Errors{}
+	seen := map[string]bool{}
+	for i, allowance := range allowances {
+		field := apperror.Index("allowances", i, "allowanceType")
+		switch {
+		case !contains(validAllowanceTypes, allowance.AllowanceType):
+			errs = append(errs, apperror.New(apperror.UnknownType, field, "allowanceType must be one of "+strings.Join(validAllowanceTypes, ", ")))
+		case seen[allowance.AllowanceType]:
+			errs = append(errs, apperror.New(apperror.ConflictingFields, field, "allowance "+allowance.AllowanceType+" is given more than once"))
+		}
+		seen[allowance.AllowanceType] = true
+
+		if err := validateAmount(apperror.Index("allowances", i, "amount"), allowance.Amount); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func validateAmount(field string, amount float64) *apperror.Error {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return apperror.New(apperror.InvalidFormat, field, field+" must be a finite number")
+	}
+	if amount < 0 {
+		return apperror.New(apperror.OutOfRange, field, field+" must be greater than or equal to 0")
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
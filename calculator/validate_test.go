@@ -0,0 +1,83 @@
+package calculator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TonRat/assessment-tax/apperror"
+)
+
+func TestValidateTaxInput(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		allowances := []Allowance{{AllowanceType: "donation", Amount: 200000.0}, {AllowanceType: "k-receipt", Amount: 0.0}}
+
+		errs := ValidateTaxInput(500000.0, 25000.0, allowances)
+
+		assert.Nil(t, errs.Err(), "Should not be error")
+	})
+
+	t.Run("ReportAllProblems", func(t *testing.T) {
+		allowances := []Allowance{
+			{AllowanceType: "donation", Amount: -1.0},
+			{AllowanceType: "rmf", Amount: 10000.0},
+			{AllowanceType: "donation", Amount: math.Inf(1)},
+		}
+
+		errs := ValidateTaxInput(-500000.0, math.NaN(), allowances)
+
+		expected := apperror.Errors{
+			apperror.New(apperror.OutOfRange, "totalIncome", "totalIncome must be greater than or equal to 0"),
+			apperror.New(apperror.InvalidFormat, "wht", "wht must be a finite number"),
+			apperror.New(apperror.OutOfRange, "allowances[0].amount", "allowances[0].amount must be greater than or equal to 0"),
+			apperror.New(apperror.UnknownType, "allowances[1].allowanceType", "allowanceType must be one of spouse, k-receipt, donation"),
+			apperror.New(apperror.ConflictingFields, "allowances[2].allowanceType", "allowance donation is given more than once"),
+			apperror.New(apperror.InvalidFormat, "allowances[2].amount", "allowances[2].amount must be a finite number"),
+		}
+		assert.Equal(t, expected, errs, "Wrong errors")
+	})
+
+	t.Run("WhtOnlyComparedWithValidTotalIncome", func(t *testing.T) {
+		assert.Equal(t, 1, len(ValidateTaxInput(-1.0, 25000.0, nil)), "Should only report totalIncome")
+		assert.Equal(t, apperror.Errors{apperror.New(apperror.OutOfRange, "wht", "wht must be between 0 and totalIncome")}, ValidateTaxInput(10000.0, 25000.0, nil), "Wrong errors")
+	})
+}
+
+func TestValidateIncomeInput(t *testing.T) {
+	err := SetExchangeRates([]ExchangeRate{{Currency: "USD", Date: "2024-04-01", Rate: 36.45}})
+	assert.Nil(t, err, "Should not be error")
+
+	t.Run("ReportAllProblems", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(1)", Amount: 100000.0}, {IncomeType: "40(2)", Amount: -1.0}}
+		investments := []Investment{{InvestmentType: "dividend", Amount: math.NaN()}}
+		foreignIncomes := []ForeignIncome{{IncomeType: "40(2)", Currency: "USD", Amount: math.Inf(1), RemittanceDate: "2024-05-15"}}
+
+		errs := ValidateIncomeInput(incomes, investments, foreignIncomes, -1.0, []Allowance{{AllowanceType: "rmf", Amount: 10000.0}})
+
+		expected := apperror.Errors{
+			apperror.New(apperror.OutOfRange, "incomes[1].amount", "incomes[1].amount must be greater than or equal to 0"),
+			apperror.New(apperror.InvalidFormat, "investments[0].amount", "investments[0].amount must be a finite number"),
+			apperror.New(apperror.InvalidFormat, "foreignIncomes[0].amount", "foreignIncomes[0].amount must be a finite number"),
+			apperror.New(apperror.OutOfRange, "wht", "wht must be between 0 and totalIncome"),
+			apperror.New(apperror.UnknownType, "allowances[0].allowanceType", "allowanceType must be one of spouse, k-receipt, donation"),
+		}
+		assert.Equal(t, expected, errs, "Wrong errors")
+	})
+
+	t.Run("WhtComparedWithGrossIncome", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(1)", Amount: 100000.0}}
+		investments := []Investment{{InvestmentType: "interest", Amount: 50000.0}}
+		foreignIncomes := []ForeignIncome{{IncomeType: "40(2)", Currency: "USD", Amount: 1000.0, RemittanceDate: "2024-05-15"}}
+
+		assert.Nil(t, ValidateIncomeInput(incomes, investments, foreignIncomes, 186450.0, nil).Err(), "Should not be error")
+		assert.Equal(t, apperror.Errors{apperror.New(apperror.OutOfRange, "wht", "wht must be between 0 and totalIncome")}, ValidateIncomeInput(incomes, investments, foreignIncomes, 186450.01, nil), "Wrong errors")
+	})
+
+	t.Run("WhtOnlyComparedWithValidIncomes", func(t *testing.T) {
+		incomes := []Income{{IncomeType: "40(1)", Amount: -100000.0}}
+
+		assert.Equal(t, 1, len(ValidateIncomeInput(incomes, nil, nil, 25000.0, nil)), "Should only report the income")
+		assert.Nil(t, ValidateIncomeInput(nil, nil, []ForeignIncome{{IncomeType: "40(2)", Currency: "EUR", Amount: 1000.0, RemittanceDate: "2024-05-15"}}, 25000.0, nil).Err(), "Should leave unconvertible incomes to the calculation")
+	})
+}
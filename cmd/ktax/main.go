@@ -0,0 +1,219 @@
+// Command ktax calculates income tax without the HTTP server.
+//
+//	ktax -income 500000 -wht 0 -donation 200000   one calculation from flags
+//	ktax -json < request.json                     one calculation from JSON
+//	ktax batch [-format csv|json] [file.csv]      every row of a CSV file
+//
+// The JSON request has totalIncome, wht and allowances as in
+// tax/calculations, and the CSV file has the format of
+// tax/calculations/upload-csv. Results are written to stdout in the v2
+// response format, and problems to stderr. The exit code tells the kind of
+// failure, see the exit constants.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/TonRat/assessment-tax/taxHandler"
+	"github.com/TonRat/assessment-tax/uploadCSV"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+const (
+	exitOK = 0
+	// exitError is a failure that is not about the input, like a file that
+	// cannot be read.
+	exitError = 1
+	// exitUsage is a wrong flag or subcommand.
+	exitUsage = 2
+	// exitInvalid is input that fails validation.
+	exitInvalid = 3
+)
+
+type input struct {
+	TotalIncome float64                `json:"totalIncome"`
+	WHT         float64                `json:"wht" required:"true"`
+	Allowances  []calculator.Allowance `json:"allowances"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "batch" {
+		return batch(args[1:], stdin, stdout, stderr)
+	}
+	return single(args, stdin, stdout, stderr)
+}
+
+func single(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ktax", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	fromJSON := flags.Bool("json", false, "read the request as JSON from stdin")
+	totalIncome := flags.Float64("income", 0, "total income")
+	wht := flags.Float64("wht", 0, "withholding tax")
+	explain := flags.Bool("explain", false, "explain every step of the calculation")
+	lang := flags.String("lang", "", "language of labels: th or en")
+	amounts := map[string]*float64{}
+	for _, allowanceType := range []string{"spouse", "k-receipt", "donation"} {
+		amounts[allowanceType] = flags.Float64(allowanceType, 0, allowanceType+" allowance")
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "ktax: unexpected argument %q\n", flags.Arg(0))
+		return exitUsage
+	}
+
+	var in input
+	if *fromJSON {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if err := strictjson.Unmarshal(data, &in); err != nil {
+			return fail(stderr, err)
+		}
+	} else {
+		in = input{TotalIncome: *totalIncome, WHT: *wht, Allowances: []calculator.Allowance{}}
+		// only the allowances given are claimed, in the order of the flags
+		flags.Visit(func(f *flag.Flag) {
+			if amount, ok := amounts[f.Name]; ok {
+				in.Allowances = append(in.Allowances, calculator.Allowance{AllowanceType: f.Name, Amount: *amount})
+			}
+		})
+	}
+
+	if err := calculator.ValidateTaxInput(in.TotalIncome, in.WHT, in.Allowances).Err(); err != nil {
+		return fail(stderr, err)
+	}
+	result, err := calculator.CalculateTaxDetail(in.TotalIncome, in.WHT, in.Allowances)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if !*explain {
+		result.Trace = nil
+	}
+
+	res := taxHandler.TaxResponseV2{
+		Tax:         math.Max(result.Tax, 0),
+		TaxRefund:   math.Max(-result.Tax, 0),
+		TaxLevels:   calculator.LocalizeTaxLevels(*lang, result.TaxLevels),
+		TaxRate:     calculator.LocalizeTaxRate(*lang, result.TaxRate),
+		Explanation: calculator.LocalizeSteps(*lang, result.Trace),
+	}
+	return write(stderr, writeJSON(stdout, res))
+}
+
+func batch(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ktax batch", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "csv", "output format: csv or json")
+	explain := flags.Bool("explain", false, "explain every step of the calculations, json only")
+	lang := flags.String("lang", "", "language of labels: th or en")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintf(stderr, "ktax: format must be csv or json\n")
+		return exitUsage
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintf(stderr, "ktax: batch reads one file\n")
+		return exitUsage
+	}
+
+	src := stdin
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return fail(stderr, err)
+		}
+		defer f.Close()
+		src = f
+	}
+
+	records, err := uploadcsv.ReadTaxRecords(src, *lang, *explain)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	if *format == "json" {
+		return write(stderr, writeJSON(stdout, uploadcsv.TaxResponseCSVV2{Taxes: records}))
+	}
+	return write(stderr, writeCSV(stdout, records))
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// writeCSV writes the records with the taxpayer columns first, which are
+// empty when the input has none.
+func writeCSV(w io.Writer, records []uploadcsv.TaxRecordV2) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"taxpayerId", "firstName", "lastName", "totalIncome", "tax", "taxRefund"})
+	for _, record := range records {
+		writer.Write([]string{
+			record.TaxpayerID,
+			record.FirstName,
+			record.LastName,
+			formatAmount(record.TotalIncome),
+			formatAmount(record.Tax),
+			formatAmount(record.TaxRefund),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+func write(stderr io.Writer, err error) int {
+	if err != nil {
+		return fail(stderr, err)
+	}
+	return exitOK
+}
+
+// fail writes every problem of err on its own line, with the field and the
+// CSV row when known.
+func fail(stderr io.Writer, err error) int {
+	var errs apperror.Errors
+	var e *apperror.Error
+	switch {
+	case errors.As(err, &errs):
+		for _, e := range errs {
+			fmt.Fprintln(stderr, "ktax: "+describe(e))
+		}
+		return exitInvalid
+	case errors.As(err, &e):
+		fmt.Fprintln(stderr, "ktax: "+describe(e))
+		return exitInvalid
+	default:
+		fmt.Fprintln(stderr, "ktax: "+err.Error())
+		return exitError
+	}
+}
+
+func describe(e *apperror.Error) string {
+	text := string(e.Code) + ": " + e.Message
+	if row, ok := e.Details["row"]; ok {
+		text = fmt.Sprintf("row %v: %s", row, text)
+	}
+	return text
+}
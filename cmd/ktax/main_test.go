@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TonRat/assessment-tax/taxHandler"
+	"github.com/TonRat/assessment-tax/uploadCSV"
+)
+
+func ktax(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSingle(t *testing.T) {
+	t.Run("Flags", func(t *testing.T) {
+		code, stdout, stderr := ktax("", "-income", "500000", "-donation", "200000")
+
+		var res taxHandler.TaxResponseV2
+		err := json.Unmarshal([]byte(stdout), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, exitOK, code, stderr)
+		assert.Equal(t, 19000.0, res.Tax, "Tax should be 19000.0")
+		assert.Equal(t, 0.0, res.TaxRefund, "Tax refund should be 0.0")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		code, stdout, stderr := ktax(`{"totalIncome": 500000.0, "wht": 40000.0, "allowances": []}`, "-json", "-lang", "en")
+
+		var res taxHandler.TaxResponseV2
+		err := json.Unmarshal([]byte(stdout), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, exitOK, code, stderr)
+		assert.Equal(t, 11000.0, res.TaxRefund, "Tax refund should be 11000.0")
+		assert.Equal(t, "2,000,001 and above", res.TaxLevels[4].Level)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		code, stdout, stderr := ktax(`{"totalincome": 500000.0, "wht": -1.0}`, "-json")
+
+		assert.Equal(t, exitInvalid, code)
+		assert.Empty(t, stdout)
+		assert.Equal(t, "ktax: unknown_field: totalincome is not a known field\n", stderr)
+	})
+
+	t.Run("InvalidFlags", func(t *testing.T) {
+		code, _, stderr := ktax("", "-income", "100", "-wht", "200")
+
+		assert.Equal(t, exitInvalid, code)
+		assert.Equal(t, "ktax: out_of_range: wht must be between 0 and totalIncome\n", stderr)
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		code, _, _ := ktax("", "-salary", "100")
+
+		assert.Equal(t, exitUsage, code)
+	})
+}
+
+func TestBatch(t *testing.T) {
+	csv := "totalIncome,wht,donation,taxpayerId\n500000,0,0,1101700203948\n600000,40000,20000,\n"
+
+	t.Run("CSV", func(t *testing.T) {
+		code, stdout, stderr := ktax(csv, "batch")
+
+		expected := "taxpayerId,firstName,lastName,totalIncome,tax,taxRefund\n" +
+			"1101700203948,,,500000,29000,0\n" +
+			",,,600000,0,2000\n"
+		assert.Equal(t, exitOK, code, stderr)
+		assert.Equal(t, expected, stdout)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		code, stdout, stderr := ktax(csv, "batch", "-format", "json")
+
+		var res uploadcsv.TaxResponseCSVV2
+		err := json.Unmarshal([]byte(stdout), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, exitOK, code, stderr)
+		assert.Len(t, res.Taxes, 2)
+		assert.Equal(t, 2000.0, res.Taxes[1].TaxRefund, "Tax refund should be 2000.0")
+	})
+
+	t.Run("InvalidRows", func(t *testing.T) {
+		code, stdout, stderr := ktax("totalIncome,wht,donation\nx,0,0\n100,200,0\n", "batch")
+
+		expected := "ktax: row 2: invalid_format: totalIncome must be a number\n" +
+			"ktax: row 3: out_of_range: wht must be between 0 and totalIncome\n"
+		assert.Equal(t, exitInvalid, code)
+		assert.Empty(t, stdout)
+		assert.Equal(t, expected, stderr)
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		code, _, _ := ktax("", "batch", "missing.csv")
+
+		assert.Equal(t, exitError, code)
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		code, _, stderr := ktax(csv, "batch", "-format", "xml")
+
+		assert.Equal(t, exitUsage, code)
+		assert.Equal(t, "ktax: format must be csv or json\n", stderr)
+	})
+}
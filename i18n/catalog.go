@@ -0,0 +1,262 @@
+package i18n
+
+var catalogs = map[string]map[string]string{
+	English: {
+		// tax levels
+		"0-150,000":           "0-150,000",
+		"150,001-500,000":     "150,001-500,000",
+		"500,001-1,000,000":   "500,001-1,000,000",
+		"1,000,001-2,000,000": "1,000,001-2,000,000",
+		"2,000,001 ขึ้นไป":    "2,000,001 and above",
+
+		// explanation steps
+		"gross income":                  "gross income",
+		"net income":                    "net income",
+		"expense {incomeType}":          "expense {incomeType}",
+		"personal deduction":            "personal deduction",
+		"spouse":                        "spouse",
+		"k-receipt":                     "k-receipt",
+		"donation":                      "donation",
+		"taxable income":                "taxable income",
+		"tax {level}":                   "tax {level}",
+		"progressive tax":               "progressive tax",
+		"alternative tax":               "alternative tax",
+		"investment income 40(4)":       "investment income 40(4)",
+		"investment withholding credit": "investment withholding credit",
+		"dividend credit":               "dividend credit",
+		"investment final withholding":  "investment final withholding",
+		"wht credit":                    "wht credit",
+		"foreign tax credit":            "foreign tax credit",
+		"half-year tax credit":          "half-year tax credit",
+		"tax":                           "tax",
+		"tax refund":                    "tax refund",
+
+		// explanation notes
+		"applied in full":                "applied in full",
+		"clipped by {allowance} cap":     "clipped by {allowance} cap",
+		"clipped by {group} expense cap": "clipped by {group} expense cap",
+		"flat rate":                      "flat rate",
+		"actual expense":                 "actual expense",
+		"clipped by Thai tax attributable to foreign income":                               "clipped by Thai tax attributable to foreign income",
+		"included in assessment, cheaper than final withholding":                           "included in assessment, cheaper than final withholding",
+		"not included in assessment, cheaper than including":                               "not included in assessment, cheaper than including",
+		"income other than 40(1) is less than {amount}, alternative method not applicable": "income other than 40(1) is less than {amount}, alternative method not applicable",
+		"alternative tax is not more than 5,000 and is exempted":                           "alternative tax is not more than 5,000 and is exempted",
+		"0.5% of income other than 40(1) is more than progressive tax":                     "0.5% of income other than 40(1) is more than progressive tax",
+		"progressive tax is more than or equal to 0.5% of income other than 40(1)":         "progressive tax is more than or equal to 0.5% of income other than 40(1)",
+
+		// problem titles
+		"Bad Request":           "Bad Request",
+		"Unauthorized":          "Unauthorized",
+		"Not Found":             "Not Found",
+		"Method Not Allowed":    "Method Not Allowed",
+		"Internal Server Error": "Internal Server Error",
+
+		// errors
+		"{field} must be a finite number":                                  "{field} must be a finite number",
+		"{field} must be greater than or equal to 0":                       "{field} must be greater than or equal to 0",
+		"allowanceType must be one of {types}":                             "allowanceType must be one of {types}",
+		"allowance {allowanceType} is given more than once":                "allowance {allowanceType} is given more than once",
+		"request has {count} problems":                                     "request has {count} problems",
+		"request body cannot be read":                                      "request body cannot be read",
+		"request body must be valid JSON":                                  "request body must be valid JSON",
+		"request body must be a single JSON value":                         "request body must be a single JSON value",
+		"request body must not be null":                                    "request body must not be null",
+		"{field} is not a known field":                                     "{field} is not a known field",
+		"{field} is required":                                              "{field} is required",
+		"{field} must be a number":                                         "{field} must be a number",
+		"{field} must be an integer":                                       "{field} must be an integer",
+		"{field} must be a string":                                         "{field} must be a string",
+		"{field} must be a boolean":                                        "{field} must be a boolean",
+		"{field} must be an array":                                         "{field} must be an array",
+		"{field} must be an object":                                        "{field} must be an object",
+		"batch must have 1 to 1000 calculations":                           "batch must have 1 to 1000 calculations",
+		"id is given more than once":                                       "id is given more than once",
+		"Content-Type must be application/x-ndjson or text/csv":            "Content-Type must be application/x-ndjson or text/csv",
+		"line is longer than 1 MiB":                                        "line is longer than 1 MiB",
+		"row is longer than 1 MiB":                                         "row is longer than 1 MiB",
+		"row must have as many fields as the header":                       "row must have as many fields as the header",
+		"{scope}: {message}":                                               "{scope}: {message}",
+		"wht must be between 0 and totalIncome":                            "wht must be between 0 and totalIncome",
+		"totalIncome must be greater than or equal to 0":                   "totalIncome must be greater than or equal to 0",
+		"totalIncome cannot be used together with incomes or investments":  "totalIncome cannot be used together with incomes or investments",
+		"kReceiptAmount must be greater than 0":                            "kReceiptAmount must be greater than 0",
+		"donation must be greater than 0":                                  "donation must be greater than 0",
+		"spouse must be greater than 0":                                    "spouse must be greater than 0",
+		"incomeType must be one of 40(1) to 40(8)":                         "incomeType must be one of 40(1) to 40(8)",
+		"income amount must be greater than or equal to 0":                 "income amount must be greater than or equal to 0",
+		"actualExpense must be between 0 and amount":                       "actualExpense must be between 0 and amount",
+		"actualExpense is only allowed for income 40(5) to 40(8)":          "actualExpense is only allowed for income 40(5) to 40(8)",
+		"investmentType must be dividend or interest":                      "investmentType must be dividend or interest",
+		"investment amount must be greater than or equal to 0":             "investment amount must be greater than or equal to 0",
+		"corporateTaxRate must be between 0 and 1":                         "corporateTaxRate must be between 0 and 1",
+		"investments are not supported by advice":                          "investments are not supported by advice",
+		"{field} cannot be used with advice":                               "{field} cannot be used with advice",
+		"advice is only given for the annual period":                       "advice is only given for the annual period",
+		"period must be annual or half-year":                               "period must be annual or half-year",
+		"half-year period only allows income 40(5) to 40(8)":               "half-year period only allows income 40(5) to 40(8)",
+		"half-year period requires incomes 40(5) to 40(8)":                 "half-year period requires incomes 40(5) to 40(8)",
+		"halfYearTax must be greater than or equal to 0":                   "halfYearTax must be greater than or equal to 0",
+		"halfYearTax can only be credited in annual period":                "halfYearTax can only be credited in annual period",
+		"tax must be greater than or equal to 0":                           "tax must be greater than or equal to 0",
+		"paymentDate must be in YYYY-MM-DD format":                         "paymentDate must be in YYYY-MM-DD format",
+		"amount must be greater than or equal to 0":                        "amount must be greater than or equal to 0",
+		"target must be tax or net":                                        "target must be tax or net",
+		"currency must be a 3-letter code":                                 "currency must be a 3-letter code",
+		"date must be in YYYY-MM-DD format":                                "date must be in YYYY-MM-DD format",
+		"rate must be greater than 0":                                      "rate must be greater than 0",
+		"rates must not be empty":                                          "rates must not be empty",
+		"exchange rate header must be currency,date,rate":                  "exchange rate header must be currency,date,rate",
+		"line {line}: rate must be a number":                               "line {line}: rate must be a number",
+		"no exchange rate for {currency} on or before {date}":              "no exchange rate for {currency} on or before {date}",
+		"foreignTax must be between 0 and amount":                          "foreignTax must be between 0 and amount",
+		"remittanceDate must be in YYYY-MM-DD format":                      "remittanceDate must be in YYYY-MM-DD format",
+		"remittanceDate must be in tax year {year}":                        "remittanceDate must be in tax year {year}",
+		"foreign income must be part of gross income":                      "foreign income must be part of gross income",
+		"taxpayerId must be 13 digits":                                     "taxpayerId must be 13 digits",
+		"taxpayerId check digit is invalid":                                "taxpayerId check digit is invalid",
+		"firstName must be at most 100 characters":                         "firstName must be at most 100 characters",
+		"lastName must be at most 100 characters":                          "lastName must be at most 100 characters",
+		"scenario name must be unique and not empty":                       "scenario name must be unique and not empty",
+		"startMonth must be between 1 and 12":                              "startMonth must be between 1 and 12",
+		"monthlySalary must be greater than or equal to 0":                 "monthlySalary must be greater than or equal to 0",
+		"bonus month must be between startMonth and 12":                    "bonus month must be between startMonth and 12",
+		"bonus amount must be greater than or equal to 0":                  "bonus amount must be greater than or equal to 0",
+		"personalDeduction amount must be greater than or equal to 10,000": "personalDeduction amount must be greater than or equal to 10,000",
+		"kReceipt amount must be greater than or equal to 0":               "kReceipt amount must be greater than or equal to 0",
+		"failed to read CSV header":                                        "failed to read CSV header",
+		"invalid CSV header format":                                        "invalid CSV header format",
+		"totalIncome must be a number":                                     "totalIncome must be a number",
+		"wht must be a number":                                             "wht must be a number",
+		"donation must be a number":                                        "donation must be a number",
+	},
+	Thai: {
+		// tax levels
+		"0-150,000":           "0-150,000",
+		"150,001-500,000":     "150,001-500,000",
+		"500,001-1,000,000":   "500,001-1,000,000",
+		"1,000,001-2,000,000": "1,000,001-2,000,000",
+		"2,000,001 ขึ้นไป":    "2,000,001 ขึ้นไป",
+
+		// explanation steps
+		"gross income":                  "เงินได้พึงประเมิน",
+		"net income":                    "เงินได้หลังหักค่าใช้จ่าย",
+		"expense {incomeType}":          "ค่าใช้จ่ายเงินได้ {incomeType}",
+		"personal deduction":            "ค่าลดหย่อนส่วนตัว",
+		"spouse":                        "ค่าลดหย่อนคู่สมรส",
+		"k-receipt":                     "ค่าลดหย่อน k-receipt",
+		"donation":                      "เงินบริจาค",
+		"taxable income":                "เงินได้สุทธิ",
+		"tax {level}":                   "ภาษีขั้น {level}",
+		"progressive tax":               "ภาษีอัตราก้าวหน้า",
+		"alternative tax":               "ภาษีวิธีที่ 2 (0.5% ของเงินได้)",
+		"investment income 40(4)":       "เงินได้จากการลงทุน 40(4)",
+		"investment withholding credit": "ภาษีหัก ณ ที่จ่ายจากการลงทุน",
+		"dividend credit":               "เครดิตภาษีเงินปันผล",
+		"investment final withholding":  "ภาษีหัก ณ ที่จ่ายจากการลงทุนแบบไม่นำมารวมคำนวณ",
+		"wht credit":                    "ภาษีหัก ณ ที่จ่าย",
+		"foreign tax credit":            "เครดิตภาษีต่างประเทศ",
+		"half-year tax credit":          "ภาษีครึ่งปีที่ชำระแล้ว",
+		"tax":                           "ภาษีที่ต้องชำระ",
+		"tax refund":                    "ภาษีที่ได้รับคืน",
+
+		// explanation notes
+		"applied in full":                "หักได้เต็มจำนวน",
+		"clipped by {allowance} cap":     "หักได้ไม่เกินเพดาน{allowance}",
+		"clipped by {group} expense cap": "หักได้ไม่เกินเพดานค่าใช้จ่ายเงินได้ {group}",
+		"flat rate":                      "หักค่าใช้จ่ายแบบเหมา",
+		"actual expense":                 "หักค่าใช้จ่ายตามจริง",
+		"clipped by Thai tax attributable to foreign income":                               "หักได้ไม่เกินภาษีไทยส่วนของเงินได้จากต่างประเทศ",
+		"included in assessment, cheaper than final withholding":                           "นำมารวมคำนวณภาษี ถูกกว่าไม่นำมารวม",
+		"not included in assessment, cheaper than including":                               "ไม่นำมารวมคำนวณภาษี ถูกกว่านำมารวม",
+		"income other than 40(1) is less than {amount}, alternative method not applicable": "เงินได้นอกจาก 40(1) น้อยกว่า {amount} บาท ไม่ต้องคำนวณภาษีวิธีที่ 2",
+		"alternative tax is not more than 5,000 and is exempted":                           "ภาษีวิธีที่ 2 ไม่เกิน 5,000 บาท ได้รับยกเว้น",
+		"0.5% of income other than 40(1) is more than progressive tax":                     "0.5% ของเงินได้นอกจาก 40(1) มากกว่าภาษีอัตราก้าวหน้า",
+		"progressive tax is more than or equal to 0.5% of income other than 40(1)":         "ภาษีอัตราก้าวหน้ามากกว่าหรือเท่ากับ 0.5% ของเงินได้นอกจาก 40(1)",
+
+		// problem titles
+		"Bad Request":           "คำขอไม่ถูกต้อง",
+		"Unauthorized":          "ไม่ได้รับอนุญาต",
+		"Not Found":             "ไม่พบ",
+		"Method Not Allowed":    "ไม่รองรับ method นี้",
+		"Internal Server Error": "ระบบขัดข้อง",
+
+		// errors
+		"{field} must be a finite number":                                  "{field} ต้องเป็นตัวเลขที่ไม่ใช่ NaN หรือ Infinity",
+		"{field} must be greater than or equal to 0":                       "{field} ต้องมากกว่าหรือเท่ากับ 0",
+		"allowanceType must be one of {types}":                             "allowanceType ต้องเป็น {types}",
+		"allowance {allowanceType} is given more than once":                "ระบุ{allowanceType}ซ้ำมากกว่าหนึ่งครั้ง",
+		"request has {count} problems":                                     "คำขอมีข้อผิดพลาด {count} รายการ",
+		"request body cannot be read":                                      "อ่าน request body ไม่ได้",
+		"request body must be valid JSON":                                  "request body ต้องเป็น JSON ที่ถูกต้อง",
+		"request body must be a single JSON value":                         "request body ต้องมี JSON เพียงค่าเดียว",
+		"request body must not be null":                                    "request body ต้องไม่เป็น null",
+		"{field} is not a known field":                                     "ไม่รู้จัก field {field}",
+		"{field} is required":                                              "ต้องระบุ {field}",
+		"{field} must be a number":                                         "{field} ต้องเป็นตัวเลข",
+		"{field} must be an integer":                                       "{field} ต้องเป็นจำนวนเต็ม",
+		"{field} must be a string":                                         "{field} ต้องเป็นข้อความ",
+		"{field} must be a boolean":                                        "{field} ต้องเป็น true หรือ false",
+		"{field} must be an array":                                         "{field} ต้องเป็น array",
+		"{field} must be an object":                                        "{field} ต้องเป็น object",
+		"batch must have 1 to 1000 calculations":                           "batch ต้องมีรายการคำนวน 1 ถึง 1000 รายการ",
+		"id is given more than once":                                       "ระบุ id ซ้ำมากกว่าหนึ่งครั้ง",
+		"Content-Type must be application/x-ndjson or text/csv":            "Content-Type ต้องเป็น application/x-ndjson หรือ text/csv",
+		"line is longer than 1 MiB":                                        "บรรทัดยาวเกิน 1 MiB",
+		"row is longer than 1 MiB":                                         "แถวยาวเกิน 1 MiB",
+		"row must have as many fields as the header":                       "จำนวนช่องของแถวต้องเท่ากับ header",
+		"{scope}: {message}":                                               "{scope}: {message}",
+		"wht must be between 0 and totalIncome":                            "wht ต้องอยู่ระหว่าง 0 ถึง totalIncome",
+		"totalIncome must be greater than or equal to 0":                   "totalIncome ต้องมากกว่าหรือเท่ากับ 0",
+		"totalIncome cannot be used together with incomes or investments":  "ใช้ totalIncome ร่วมกับ incomes หรือ investments ไม่ได้",
+		"kReceiptAmount must be greater than 0":                            "k-receipt ต้องมากกว่า 0",
+		"donation must be greater than 0":                                  "donation ต้องมากกว่า 0",
+		"spouse must be greater than 0":                                    "spouse ต้องมากกว่า 0",
+		"incomeType must be one of 40(1) to 40(8)":                         "incomeType ต้องเป็น 40(1) ถึง 40(8)",
+		"income amount must be greater than or equal to 0":                 "amount ของเงินได้ต้องมากกว่าหรือเท่ากับ 0",
+		"actualExpense must be between 0 and amount":                       "actualExpense ต้องอยู่ระหว่าง 0 ถึง amount",
+		"actualExpense is only allowed for income 40(5) to 40(8)":          "actualExpense ใช้ได้เฉพาะเงินได้ 40(5) ถึง 40(8)",
+		"investmentType must be dividend or interest":                      "investmentType ต้องเป็น dividend หรือ interest",
+		"investment amount must be greater than or equal to 0":             "amount ของเงินลงทุนต้องมากกว่าหรือเท่ากับ 0",
+		"corporateTaxRate must be between 0 and 1":                         "corporateTaxRate ต้องอยู่ระหว่าง 0 ถึง 1",
+		"investments are not supported by advice":                          "คำแนะนำค่าลดหย่อนไม่รองรับ investments",
+		"{field} cannot be used with advice":                               "คำแนะนำค่าลดหย่อนไม่รองรับ {field}",
+		"advice is only given for the annual period":                       "คำแนะนำค่าลดหย่อนรองรับเฉพาะการยื่นภาษีทั้งปี",
+		"period must be annual or half-year":                               "period ต้องเป็น annual หรือ half-year",
+		"half-year period only allows income 40(5) to 40(8)":               "ภาษีครึ่งปีใช้ได้เฉพาะเงินได้ 40(5) ถึง 40(8)",
+		"half-year period requires incomes 40(5) to 40(8)":                 "ภาษีครึ่งปีต้องระบุ incomes 40(5) ถึง 40(8)",
+		"halfYearTax must be greater than or equal to 0":                   "halfYearTax ต้องมากกว่าหรือเท่ากับ 0",
+		"halfYearTax can only be credited in annual period":                "halfYearTax ใช้เครดิตได้เฉพาะภาษีทั้งปี",
+		"tax must be greater than or equal to 0":                           "tax ต้องมากกว่าหรือเท่ากับ 0",
+		"paymentDate must be in YYYY-MM-DD format":                         "paymentDate ต้องอยู่ในรูปแบบ YYYY-MM-DD",
+		"amount must be greater than or equal to 0":                        "amount ต้องมากกว่าหรือเท่ากับ 0",
+		"target must be tax or net":                                        "target ต้องเป็น tax หรือ net",
+		"currency must be a 3-letter code":                                 "currency ต้องเป็นรหัส 3 ตัวอักษร",
+		"date must be in YYYY-MM-DD format":                                "date ต้องอยู่ในรูปแบบ YYYY-MM-DD",
+		"rate must be greater than 0":                                      "rate ต้องมากกว่า 0",
+		"rates must not be empty":                                          "ต้องระบุ rates อย่างน้อยหนึ่งรายการ",
+		"exchange rate header must be currency,date,rate":                  "header ของอัตราแลกเปลี่ยนต้องเป็น currency,date,rate",
+		"line {line}: rate must be a number":                               "บรรทัด {line}: rate ต้องเป็นตัวเลข",
+		"no exchange rate for {currency} on or before {date}":              "ไม่มีอัตราแลกเปลี่ยน {currency} ในวันที่ {date} หรือก่อนหน้า",
+		"foreignTax must be between 0 and amount":                          "foreignTax ต้องอยู่ระหว่าง 0 ถึง amount",
+		"remittanceDate must be in YYYY-MM-DD format":                      "remittanceDate ต้องอยู่ในรูปแบบ YYYY-MM-DD",
+		"remittanceDate must be in tax year {year}":                        "remittanceDate ต้องอยู่ในปีภาษี {year}",
+		"foreign income must be part of gross income":                      "เงินได้ต่างประเทศต้องเป็นส่วนหนึ่งของเงินได้พึงประเมิน",
+		"taxpayerId must be 13 digits":                                     "taxpayerId ต้องเป็นตัวเลข 13 หลัก",
+		"taxpayerId check digit is invalid":                                "taxpayerId หลักตรวจสอบไม่ถูกต้อง",
+		"firstName must be at most 100 characters":                         "firstName ยาวได้ไม่เกิน 100 ตัวอักษร",
+		"lastName must be at most 100 characters":                          "lastName ยาวได้ไม่เกิน 100 ตัวอักษร",
+		"scenario name must be unique and not empty":                       "ชื่อ scenario ต้องไม่ว่างและไม่ซ้ำกัน",
+		"startMonth must be between 1 and 12":                              "startMonth ต้องอยู่ระหว่าง 1 ถึง 12",
+		"monthlySalary must be greater than or equal to 0":                 "monthlySalary ต้องมากกว่าหรือเท่ากับ 0",
+		"bonus month must be between startMonth and 12":                    "เดือนของโบนัสต้องอยู่ระหว่าง startMonth ถึง 12",
+		"bonus amount must be greater than or equal to 0":                  "amount ของโบนัสต้องมากกว่าหรือเท่ากับ 0",
+		"personalDeduction amount must be greater than or equal to 10,000": "ค่าลดหย่อนส่วนตัวต้องมากกว่าหรือเท่ากับ 10,000",
+		"kReceipt amount must be greater than or equal to 0":               "k-receipt ต้องมากกว่าหรือเท่ากับ 0",
+		"failed to read CSV header":                                        "อ่าน header ของไฟล์ CSV ไม่ได้",
+		"invalid CSV header format":                                        "header ของไฟล์ CSV ไม่ถูกต้อง",
+		"totalIncome must be a number":                                     "totalIncome ต้องเป็นตัวเลข",
+		"wht must be a number":                                             "wht ต้องเป็นตัวเลข",
+		"donation must be a number":                                        "donation ต้องเป็นตัวเลข",
+	},
+}
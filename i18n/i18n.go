@@ -0,0 +1,132 @@
+// Package i18n renders the text of responses in Thai or English. The English
+// text written in the code is the message key, as in gettext, so code that
+// does not care about language keeps working with plain strings. Keys with
+// {placeholders} match text built at runtime, and the values in the
+// placeholders are translated too, except {field} which is a JSON path.
+package i18n
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	English = "en"
+	Thai    = "th"
+)
+
+// Languages are the languages with a catalog.
+var Languages = []string{English, Thai}
+
+type template struct {
+	key          string
+	pattern      *regexp.Regexp
+	placeholders []string
+	literal      int
+}
+
+var (
+	placeholder = regexp.MustCompile(`\{(\w+)\}`)
+	// templates are the keys with placeholders, most specific first.
+	templates = compileTemplates(catalogs[English])
+)
+
+// Text renders text in the language. Text without a catalog entry and an
+// empty language, meaning none was asked for, return text unchanged.
+func Text(lang, text string) string {
+	catalog, ok := catalogs[lang]
+	if !ok {
+		return text
+	}
+	if translated, ok := catalog[text]; ok {
+		return translated
+	}
+
+	for _, t := range templates {
+		match := t.pattern.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		values := map[string]string{}
+		for i, name := range t.placeholders {
+			values[name] = match[i+1]
+			if name != "field" {
+				values[name] = Text(lang, match[i+1])
+			}
+		}
+		return placeholder.ReplaceAllStringFunc(catalog[t.key], func(p string) string {
+			return values[p[1:len(p)-1]]
+		})
+	}
+	return text
+}
+
+// FromAcceptLanguage picks the supported language the client prefers most.
+// It returns "" when there is no header, and English when none of the
+// languages in the header is supported.
+func FromAcceptLanguage(header string) string {
+	if strings.TrimSpace(header) == "" {
+		return ""
+	}
+
+	best, bestQuality := English, 0.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.SplitN(strings.TrimSpace(fields[0]), "-", 2)[0])
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err == nil {
+					quality = q
+				}
+			}
+		}
+		if _, ok := catalogs[lang]; ok && quality > bestQuality {
+			best, bestQuality = lang, quality
+		}
+	}
+	return best
+}
+
+func compileTemplates(catalog map[string]string) []template {
+	compiled := []template{}
+	for key := range catalog {
+		names := placeholder.FindAllStringSubmatch(key, -1)
+		if names == nil {
+			continue
+		}
+		t := template{key: key, literal: len(placeholder.ReplaceAllString(key, ""))}
+		pattern := "^"
+		rest := key
+		for _, name := range names {
+			i := strings.Index(rest, name[0])
+			pattern += regexp.QuoteMeta(rest[:i]) + "(.+?)"
+			rest = rest[i+len(name[0]):]
+			t.placeholders = append(t.placeholders, name[1])
+		}
+		t.pattern = regexp.MustCompile(pattern + regexp.QuoteMeta(rest) + "$")
+		compiled = append(compiled, t)
+	}
+	sort.Slice(compiled, func(i, j int) bool {
+		if compiled[i].literal != compiled[j].literal {
+			return compiled[i].literal > compiled[j].literal
+		}
+		return compiled[i].key < compiled[j].key
+	})
+	return compiled
+}
+
+// Negotiate picks the language of the response from the Accept-Language of
+// the request and sets Content-Language when a language was asked for.
+func Negotiate(r *http.Request, w http.ResponseWriter) string {
+	lang := FromAcceptLanguage(r.Header.Get("Accept-Language"))
+	if lang != "" {
+		w.Header().Set("Content-Language", lang)
+	}
+	return lang
+}
@@ -0,0 +1,160 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogs(t *testing.T) {
+	t.Run("EveryKeyInEveryLanguage", func(t *testing.T) {
+		for _, lang := range Languages {
+			for _, other := range Languages {
+				for key := range catalogs[lang] {
+					_, ok := catalogs[other][key]
+					assert.True(t, ok, "%q is in %s but not in %s", key, lang, other)
+				}
+			}
+		}
+	})
+
+	t.Run("SamePlaceholders", func(t *testing.T) {
+		for _, lang := range Languages {
+			for key, text := range catalogs[lang] {
+				assert.Equal(t, placeholders(key), placeholders(text), "Wrong placeholders of %q in %s", key, lang)
+			}
+		}
+	})
+
+	// every message written in the code must have a catalog entry
+	t.Run("EveryMessageInCatalog", func(t *testing.T) {
+		for _, message := range sourceMessages(t, "../calculator", "../taxHandler", "../uploadCSV", "../admin", "../payroll", "../apperror", "../strictjson", "../openapi") {
+			assert.True(t, inCatalog(message), "%q is not in the catalogs", message)
+		}
+	})
+}
+
+func TestText(t *testing.T) {
+	t.Run("NoLanguage", func(t *testing.T) {
+		assert.Equal(t, "2,000,001 ขึ้นไป", Text("", "2,000,001 ขึ้นไป"))
+	})
+
+	t.Run("TaxLevel", func(t *testing.T) {
+		assert.Equal(t, "2,000,001 and above", Text(English, "2,000,001 ขึ้นไป"))
+		assert.Equal(t, "2,000,001 ขึ้นไป", Text(Thai, "2,000,001 ขึ้นไป"))
+	})
+
+	t.Run("Placeholders", func(t *testing.T) {
+		assert.Equal(t, "tax 2,000,001 and above", Text(English, "tax 2,000,001 ขึ้นไป"))
+		assert.Equal(t, "หักได้ไม่เกินเพดานเงินบริจาค", Text(Thai, "clipped by donation cap"))
+		assert.Equal(t, "หักได้ไม่เกินเพดานค่าใช้จ่ายเงินได้ 40(1)-40(2)", Text(Thai, "clipped by 40(1)-40(2) expense cap"))
+		assert.Equal(t, "ไม่มีอัตราแลกเปลี่ยน USD ในวันที่ 2024-01-01 หรือก่อนหน้า", Text(Thai, "no exchange rate for USD on or before 2024-01-01"))
+	})
+
+	t.Run("FieldIsNotTranslated", func(t *testing.T) {
+		assert.Equal(t, "donation ต้องมากกว่าหรือเท่ากับ 0", Text(Thai, "donation must be greater than or equal to 0"))
+	})
+
+	t.Run("Scope", func(t *testing.T) {
+		assert.Equal(t, "spouse1: wht ต้องอยู่ระหว่าง 0 ถึง totalIncome", Text(Thai, "spouse1: wht must be between 0 and totalIncome"))
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		assert.Equal(t, "unexpected EOF", Text(Thai, "unexpected EOF"))
+	})
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: ""},
+		{header: "th", expected: Thai},
+		{header: "th-TH,th;q=0.9,en;q=0.8", expected: Thai},
+		{header: "en-US,th;q=0.5", expected: English},
+		{header: "ja,th;q=0.8,en;q=0.9", expected: English},
+		{header: "fr", expected: English},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, FromAcceptLanguage(test.header), "Wrong language for %q", test.header)
+	}
+}
+
+func placeholders(text string) []string {
+	names := []string{}
+	for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+		names = append(names, match[1])
+	}
+	sort.Strings(names)
+	return names
+}
+
+func inCatalog(text string) bool {
+	if _, ok := catalogs[English][text]; ok {
+		return true
+	}
+	for _, t := range templates {
+		if t.pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceMessages finds the string literals given as error messages to
+// apperror.New and as Step, Note or Explanation text.
+func sourceMessages(t *testing.T, dirs ...string) []string {
+	messages := []string{}
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		assert.NoError(t, err)
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+			assert.NoError(t, err)
+			ast.Inspect(f, func(n ast.Node) bool {
+				switch node := n.(type) {
+				case *ast.CallExpr:
+					if sel, ok := node.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "New" && len(node.Args) == 3 {
+						messages = appendLiteral(messages, node.Args[2])
+					}
+				case *ast.KeyValueExpr:
+					if key, ok := node.Key.(*ast.Ident); ok && (key.Name == "Step" || key.Name == "Note" || key.Name == "Explanation") {
+						messages = appendLiteral(messages, node.Value)
+					}
+				case *ast.AssignStmt:
+					if sel, ok := node.Lhs[0].(*ast.SelectorExpr); ok && (sel.Sel.Name == "Note" || sel.Sel.Name == "Explanation") {
+						messages = appendLiteral(messages, node.Rhs[0])
+					}
+					if ident, ok := node.Lhs[0].(*ast.Ident); ok && ident.Name == "note" {
+						messages = appendLiteral(messages, node.Rhs[0])
+					}
+				}
+				return true
+			})
+		}
+	}
+	return messages
+}
+
+func appendLiteral(messages []string, expr ast.Expr) []string {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return messages
+	}
+	message, err := strconv.Unquote(lit.Value)
+	if err != nil || message == "" {
+		return messages
+	}
+	return append(messages, message)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"log"
 	"net/http"
@@ -9,9 +10,10 @@ import (
 	"os/signal"
 
 	"fmt"
-	"github.com/TonRat/assessment-tax/admin"
-	"github.com/TonRat/assessment-tax/taxHandler"
-	"github.com/TonRat/assessment-tax/uploadCSV"
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/openapi"
+	"github.com/TonRat/assessment-tax/router"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -25,21 +27,26 @@ func main() {
 		log.Fatal(".env file couldn't be load")
 	}
 
-	e := echo.New()
+	err = loadExchangeRates()
+	if err != nil {
+		log.Fatal("exchange rates couldn't be load: ", err)
+	}
 
-	e.POST("/tax/calculations", taxHandler.CalculateTaxHandler)
-	e.POST("/tax/calculations/upload-csv", uploadcsv.UploadCSVHandler)
+	e := echo.New()
+	e.HTTPErrorHandler = apperror.HTTPErrorHandler
 
-	g := e.Group("/admin")
-	g.Use(middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
+	v1, err := deprecateV1(router.V1())
+	if err != nil {
+		log.Fatal("API_V1_DEPRECATION or API_V1_SUNSET couldn't be parse: ", err)
+	}
+	adminAuth := middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
 
 		if username == os.Getenv("ADMIN_USERNAME") && password == os.Getenv("ADMIN_PASSWORD") {
 			return true, nil
 		}
 		return false, nil
-	}))
-	g.POST("/deductions/personal", admin.PersonalDeductionHandler)
-	g.POST("/deductions/k-receipt", admin.KReceiptHandler)
+	})
+	openapi.Register(e, adminAuth, v1, router.V2())
 	// Start server
 	go func() {
 		if err := e.Start(":" + os.Getenv("PORT")); err != nil && err != http.ErrServerClosed {
@@ -57,3 +64,47 @@ func main() {
 		e.Logger.Fatal(err)
 	}
 }
+
+// loadExchangeRates reads the local rate table from EXCHANGE_RATES_FILE, or
+// exchangeRates.csv when it is not set. Without the file, rates can still be
+// set with the admin endpoint.
+func loadExchangeRates() error {
+	path := os.Getenv("EXCHANGE_RATES_FILE")
+	if path == "" {
+		path = "exchangeRates.csv"
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && os.Getenv("EXCHANGE_RATES_FILE") == "" {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rates, err := calculator.ReadExchangeRates(f)
+	if err != nil {
+		return err
+	}
+	return calculator.SetExchangeRates(rates)
+}
+
+// deprecateV1 deprecates v1 in favour of v2 when API_V1_DEPRECATION is set.
+// Both it and API_V1_SUNSET are dates in YYYY-MM-DD format.
+func deprecateV1(v1 router.Version) (router.Version, error) {
+	if os.Getenv("API_V1_DEPRECATION") == "" {
+		return v1, nil
+	}
+	deprecation, err := time.Parse(time.DateOnly, os.Getenv("API_V1_DEPRECATION"))
+	if err != nil {
+		return v1, err
+	}
+	var sunset time.Time
+	if os.Getenv("API_V1_SUNSET") != "" {
+		sunset, err = time.Parse(time.DateOnly, os.Getenv("API_V1_SUNSET"))
+		if err != nil {
+			return v1, err
+		}
+	}
+	return v1.Deprecate(deprecation, sunset, "v2"), nil
+}
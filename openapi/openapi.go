@@ -0,0 +1,308 @@
+// Package openapi generates the OpenAPI 3 document of the API from the
+// routes of each version and the Go types of their bodies, so the document
+// follows the code.
+package openapi
+
+import (
+	"fmt"
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/router"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Path is where the document is served.
+const Path = "/openapi.json"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps a lower case HTTP method to its operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// Generate describes the routes of the versions as router.Register mounts
+// them, and the document itself at Path.
+func Generate(versions ...router.Version) Document {
+	g := generator{
+		schemas: map[string]*Schema{},
+		types:   map[string]reflect.Type{},
+	}
+	doc := Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: "K-Tax API", Version: versionNames(versions)},
+		Paths:   map[string]PathItem{},
+	}
+
+	for _, v := range versions {
+		prefixes := []string{"/" + v.Name}
+		if v.Root {
+			prefixes = append(prefixes, "")
+		}
+		for _, prefix := range prefixes {
+			for _, route := range v.Routes {
+				doc.add(prefix+route.Path, route.Method, g.operation(v, route, false))
+			}
+			for _, route := range v.Admin {
+				doc.add(prefix+"/admin"+route.Path, route.Method, g.operation(v, route, true))
+			}
+		}
+	}
+	doc.add(Path, http.MethodGet, &Operation{
+		Summary: "This document",
+		Responses: map[string]Response{
+			"200": {Description: "OpenAPI document", Content: map[string]MediaType{echo.MIMEApplicationJSON: {Schema: &Schema{Type: "object"}}}},
+		},
+	})
+
+	doc.Components = Components{
+		Schemas:         g.schemas,
+		SecuritySchemes: map[string]SecurityScheme{"basicAuth": {Type: "http", Scheme: "basic"}},
+	}
+	return doc
+}
+
+// Handler serves doc.
+func Handler(doc Document) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, doc)
+	}
+}
+
+// Register adds the routes of the versions to e like router.Register, with
+// the JSON request bodies of the calculation and admin routes validated
+// against the document, and serves the document at Path.
+func Register(e *echo.Echo, adminAuth echo.MiddlewareFunc, versions ...router.Version) {
+	doc := Generate(versions...)
+	validate := Middleware(doc, "/tax/calculations", "/admin/*")
+	validated := make([]router.Version, len(versions))
+	for i, v := range versions {
+		v.Middleware = append(append([]echo.MiddlewareFunc{}, v.Middleware...), validate)
+		validated[i] = v
+	}
+	router.Register(e, adminAuth, validated...)
+	e.GET(Path, Handler(doc))
+}
+
+func (doc Document) add(path, method string, op *Operation) {
+	item, ok := doc.Paths[path]
+	if !ok {
+		item = PathItem{}
+		doc.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+func versionNames(versions []router.Version) string {
+	names := []string{}
+	for _, v := range versions {
+		names = append(names, v.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+type generator struct {
+	schemas map[string]*Schema
+	// types are the Go types of the component schemas, to catch two types
+	// of the same name.
+	types map[string]reflect.Type
+}
+
+func (g generator) operation(v router.Version, route router.Route, admin bool) *Operation {
+	op := &Operation{
+		Summary:    route.Summary,
+		Tags:       []string{v.Name},
+		Deprecated: !v.Deprecation.IsZero(),
+		Responses:  map[string]Response{},
+	}
+
+	switch {
+	case route.File != "":
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{
+			echo.MIMEMultipartForm: {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{route.File: {Type: "string", Format: "binary"}},
+				Required:   []string{route.File},
+			}},
+		}}
+	case len(route.Consumes) > 0:
+		// the request of a text type is described by the type alone
+		content := map[string]MediaType{}
+		for _, contentType := range route.Consumes {
+			if strings.HasPrefix(contentType, "text/") {
+				content[contentType] = MediaType{Schema: &Schema{Type: "string"}}
+			} else {
+				content[contentType] = MediaType{Schema: g.schema(reflect.TypeOf(route.Request))}
+			}
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: content}
+		op.Responses["415"] = g.problem("Unsupported content type")
+	case route.Request != nil:
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{
+			echo.MIMEApplicationJSON: {Schema: g.schema(reflect.TypeOf(route.Request))},
+		}}
+	}
+
+	produces := route.Produces
+	if produces == "" {
+		produces = echo.MIMEApplicationJSON
+	}
+	ok := Response{Description: "OK"}
+	switch len(route.Responses) {
+	case 0:
+	case 1:
+		ok.Content = map[string]MediaType{produces: {Schema: g.schema(reflect.TypeOf(route.Responses[0]))}}
+	default:
+		oneOf := &Schema{}
+		for _, res := range route.Responses {
+			oneOf.OneOf = append(oneOf.OneOf, g.schema(reflect.TypeOf(res)))
+		}
+		ok.Content = map[string]MediaType{produces: {Schema: oneOf}}
+	}
+	op.Responses["200"] = ok
+
+	op.Responses["400"] = g.problem("Invalid request")
+	if admin {
+		op.Security = []map[string][]string{{"basicAuth": {}}}
+		op.Responses["401"] = g.problem("Missing or wrong admin credentials")
+	}
+	op.Responses["500"] = g.problem("Internal error")
+	return op
+}
+
+func (g generator) problem(description string) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{apperror.ProblemContentType: {Schema: g.schema(reflect.TypeOf(apperror.Problem{}))}},
+	}
+}
+
+// schema describes t, with named structs as references to component
+// schemas.
+func (g generator) schema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := t.Name()
+		if other, ok := g.types[name]; ok {
+			if other != t {
+				panic(fmt.Sprintf("openapi: %s and %s have the same name", other, t))
+			}
+		} else {
+			g.types[name] = t
+			g.schemas[name] = g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// object describes the JSON fields of a struct, including those of embedded
+// structs. Fields tagged `required:"true"` are required, and no other fields
+// are allowed, as strictjson decodes them.
+func (g generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: new(bool)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			embedded := g.object(f.Type)
+			for name, property := range embedded.Properties {
+				s.Properties[name] = property
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schema(f.Type)
+		if f.Tag.Get("required") == "true" {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
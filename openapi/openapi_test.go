@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TonRat/assessment-tax/router"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// newServer registers the routes with Register, as main does.
+func newServer(versions ...router.Version) *echo.Echo {
+	e := echo.New()
+	adminAuth := middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
+		return false, nil
+	})
+	Register(e, adminAuth, versions...)
+	return e
+}
+
+func TestGenerate(t *testing.T) {
+	versions := []router.Version{router.V1(), router.V2()}
+	doc := Generate(versions...)
+
+	t.Run("EveryRouteInDocument", func(t *testing.T) {
+		for _, route := range newServer(versions...).Routes() {
+			item, ok := doc.Paths[route.Path]
+			if !assert.True(t, ok, "%s is not in the document", route.Path) {
+				continue
+			}
+			assert.Contains(t, item, strings.ToLower(route.Method), "%s %s is not in the document", route.Method, route.Path)
+		}
+	})
+
+	t.Run("EveryOperationDescribed", func(t *testing.T) {
+		for path, item := range doc.Paths {
+			for method, op := range item {
+				assert.NotEmpty(t, op.Summary, "%s %s has no summary", method, path)
+				assert.NotEmpty(t, op.Responses["200"].Content, "%s %s has no response body", method, path)
+				if method == "post" {
+					assert.NotNil(t, op.RequestBody, "%s %s has no request body", method, path)
+				}
+			}
+		}
+	})
+
+	t.Run("EveryReferenceResolved", func(t *testing.T) {
+		data, err := json.Marshal(doc)
+		assert.NoError(t, err)
+		for _, part := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+			name := part[:strings.Index(part, `"`)]
+			assert.Contains(t, doc.Components.Schemas, name, "%s is not a component schema", name)
+		}
+	})
+
+	t.Run("TaxRequest", func(t *testing.T) {
+		schema := doc.Components.Schemas["TaxRequest"]
+
+		assert.Equal(t, "object", schema.Type)
+		assert.Equal(t, []string{"wht"}, schema.Required, "Wrong required fields")
+		assert.Equal(t, &Schema{Type: "number", Format: "double"}, schema.Properties["totalIncome"])
+		assert.Equal(t, &Schema{Type: "string"}, schema.Properties["taxpayerId"], "Should have embedded taxpayer")
+		assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/Allowance"}}, schema.Properties["allowances"])
+		assert.Equal(t, []string{"allowanceType", "amount"}, doc.Components.Schemas["Allowance"].Required)
+	})
+
+	t.Run("TaxOrRefund", func(t *testing.T) {
+		schema := doc.Paths["/tax/calculations"]["post"].Responses["200"].Content[echo.MIMEApplicationJSON].Schema
+
+		expected := []*Schema{{Ref: "#/components/schemas/TaxResponse"}, {Ref: "#/components/schemas/TaxRefundRespond"}}
+		assert.Equal(t, expected, schema.OneOf)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		op := doc.Paths["/v2/tax/calculations/stream"]["post"]
+
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/StreamItem"}, op.RequestBody.Content["application/x-ndjson"].Schema)
+		assert.Equal(t, &Schema{Type: "string"}, op.RequestBody.Content["text/csv"].Schema)
+		assert.Contains(t, op.Responses["200"].Content, "application/x-ndjson")
+		assert.Contains(t, op.Responses, "415")
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		op := doc.Paths["/v2/admin/deductions/k-receipt"]["post"]
+
+		assert.Equal(t, []map[string][]string{{"basicAuth": {}}}, op.Security)
+		assert.Contains(t, op.Responses, "401")
+		assert.Contains(t, op.Responses["400"].Content, "application/problem+json")
+	})
+
+	t.Run("Deprecated", func(t *testing.T) {
+		v1 := router.V1().Deprecate(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), time.Time{}, "v2")
+		doc := Generate(v1, router.V2())
+
+		assert.True(t, doc.Paths["/v1/tax/calculations"]["post"].Deprecated, "v1 should be deprecated")
+		assert.True(t, doc.Paths["/tax/calculations"]["post"].Deprecated, "v1 should be deprecated")
+		assert.False(t, doc.Paths["/v2/tax/calculations"]["post"].Deprecated, "v2 should not be deprecated")
+	})
+}
+
+func TestHandler(t *testing.T) {
+	e := newServer(router.V1(), router.V2())
+
+	req := httptest.NewRequest(http.MethodGet, Path, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res map[string]interface{}
+	err := json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.3", res["openapi"])
+}
@@ -0,0 +1,136 @@
+package openapi
+
+import (
+	"bytes"
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// versionPrefix is the version segment of a route path, like /v2.
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+`)
+
+// Middleware validates JSON request bodies against the document before the
+// handler runs, and answers the problems as problem+json. Only routes
+// matching one of the paths are validated, compared without the version
+// prefix; a path ending in /* matches every route under it, e.g. /admin/*.
+func Middleware(doc Document, paths ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !matches(c.Path(), paths) {
+				return next(c)
+			}
+			op := doc.Paths[c.Path()][strings.ToLower(c.Request().Method)]
+			if op == nil || op.RequestBody == nil {
+				return next(c)
+			}
+			media, ok := op.RequestBody.Content[echo.MIMEApplicationJSON]
+			if !ok {
+				return next(c)
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return apperror.Respond(c, http.StatusBadRequest, apperror.New(apperror.InvalidRequest, "", "request body cannot be read"))
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			err = doc.Validate(media.Schema, body)
+			if err != nil {
+				return apperror.Respond(c, http.StatusBadRequest, err)
+			}
+			return next(c)
+		}
+	}
+}
+
+func matches(path string, paths []string) bool {
+	path = versionPrefix.ReplaceAllString(path, "")
+	for _, p := range paths {
+		if prefix, ok := strings.CutSuffix(p, "/*"); ok && strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a JSON body against schema and returns every problem at
+// once, with the same codes and messages as strictjson. An empty body is
+// validated as {}.
+func (doc Document) Validate(schema *Schema, body []byte) error {
+	value, err := strictjson.Decode(body)
+	if err != nil {
+		return err
+	}
+	return strictjson.Check(value, schemaShape{doc: doc, schema: doc.resolve(schema)})
+}
+
+// resolve follows a reference to a component schema. A missing schema, like
+// the items of an array without one, takes any value.
+func (doc Document) resolve(schema *Schema) *Schema {
+	if schema == nil {
+		return &Schema{}
+	}
+	if schema.Ref != "" {
+		return doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// schemaShape is the JSON a schema describes. Objects are closed when they
+// have additionalProperties false, and schemas without a type, like oneOf,
+// take any value.
+type schemaShape struct {
+	doc    Document
+	schema *Schema
+}
+
+func (s schemaShape) Kind() strictjson.Kind {
+	switch s.schema.Type {
+	case "object":
+		return strictjson.Object
+	case "array":
+		return strictjson.Array
+	case "number":
+		return strictjson.Number
+	case "integer":
+		return strictjson.Integer
+	case "string":
+		return strictjson.String
+	case "boolean":
+		return strictjson.Boolean
+	}
+	return strictjson.Any
+}
+
+func (s schemaShape) Fields() []string {
+	names := make([]string, 0, len(s.schema.Properties))
+	for name := range s.schema.Properties {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s schemaShape) Field(name string) (strictjson.Shape, bool, bool) {
+	property, ok := s.schema.Properties[name]
+	if !ok {
+		return nil, false, false
+	}
+	return schemaShape{doc: s.doc, schema: s.doc.resolve(property)}, slices.Contains(s.schema.Required, name), true
+}
+
+func (s schemaShape) Closed() bool {
+	return s.schema.AdditionalProperties != nil && !*s.schema.AdditionalProperties
+}
+
+func (s schemaShape) Elem() strictjson.Shape {
+	return schemaShape{doc: s.doc, schema: s.doc.resolve(s.schema.Items)}
+}
@@ -0,0 +1,215 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/router"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/TonRat/assessment-tax/taxHandler"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// readmeExample is a request body of a story in the README, with the codes
+// and fields of the decoding errors its documented response has.
+type readmeExample struct {
+	path   string
+	body   string
+	errors map[string]apperror.Code
+}
+
+var (
+	readmeRequest  = regexp.MustCompile("(?s)`POST:` ([^\\s]+)\\n+(?:[^`\\n][^\\n]*\\n+)?```json\\n(.*?)```")
+	readmeResponse = regexp.MustCompile("(?s)^\\s*Response body( `400`)?\\s*```json\\n(.*?)```")
+)
+
+func readmeExamples(t *testing.T) []readmeExample {
+	data, err := os.ReadFile("../README.md")
+	assert.NoError(t, err)
+	readme := string(data)
+
+	examples := []readmeExample{}
+	for _, m := range readmeRequest.FindAllStringSubmatchIndex(readme, -1) {
+		path, _, _ := strings.Cut(readme[m[2]:m[3]], "?")
+		example := readmeExample{path: "/" + strings.TrimPrefix(path, "/"), body: readme[m[4]:m[5]], errors: map[string]apperror.Code{}}
+
+		// a 400 response lists the problems of the body
+		res := readmeResponse.FindStringSubmatch(readme[m[1]:])
+		if res != nil && res[1] != "" {
+			var problem apperror.Problem
+			assert.NoError(t, json.Unmarshal([]byte(res[2]), &problem), "Wrong response of %s", example.path)
+			for _, e := range append(problem.Errors, apperror.ProblemError{Code: problem.Code, Field: problem.Field}) {
+				if e.Code == apperror.UnknownField || e.Code == apperror.MissingField || e.Code == apperror.InvalidType {
+					example.errors[e.Field] = e.Code
+				}
+			}
+		}
+		examples = append(examples, example)
+	}
+	return examples
+}
+
+func TestValidate(t *testing.T) {
+	doc := Generate(router.V1(), router.V2())
+
+	t.Run("ReadmeExamples", func(t *testing.T) {
+		examples := readmeExamples(t)
+		assert.NotEmpty(t, examples, "Should find the examples")
+		for _, example := range examples {
+			op := doc.Paths[example.path]["post"]
+			if !assert.NotNil(t, op, "%s is not in the document", example.path) || op.RequestBody == nil {
+				continue
+			}
+			media, ok := op.RequestBody.Content[echo.MIMEApplicationJSON]
+			if !ok {
+				continue
+			}
+
+			err := doc.Validate(media.Schema, []byte(example.body))
+
+			errs := map[string]apperror.Code{}
+			if err != nil {
+				for _, e := range asErrors(err) {
+					errs[e.Field] = e.Code
+				}
+			}
+			assert.Equal(t, example.errors, errs, "Wrong errors of the example of %s:\n%s", example.path, example.body)
+		}
+	})
+
+	t.Run("Problems", func(t *testing.T) {
+		schema := doc.Paths["/tax/calculations"]["post"].RequestBody.Content[echo.MIMEApplicationJSON].Schema
+
+		err := doc.Validate(schema, []byte(`{"totalIncome": "1", "wht": null, "allowances": [{"allowanceType": "donation", "amount": 1, "note": ""}], "installments": 1}`))
+
+		expected := apperror.Errors{
+			apperror.New(apperror.UnknownField, "allowances[0].note", "allowances[0].note is not a known field"),
+			apperror.New(apperror.InvalidType, "installments", "installments must be a boolean"),
+			apperror.New(apperror.InvalidType, "totalIncome", "totalIncome must be a number"),
+			apperror.New(apperror.MissingField, "wht", "wht is required"),
+		}
+		assert.Equal(t, expected, err)
+	})
+
+	t.Run("SameAsStrictJSON", func(t *testing.T) {
+		schema := doc.Paths["/tax/calculations"]["post"].RequestBody.Content[echo.MIMEApplicationJSON].Schema
+		bodies := []string{
+			`{"totalIncome": "1", "wht": null, "allowances": [{"allowanceType": "donation", "note": ""}], "installments": 1}`,
+			`{"wht": 0.0, "incomes": [{"incomeType": "40(1)", "amount": 1, "actualExpense": "0"}], "taxpayerId": 1}`,
+			`null`,
+			`[]`,
+			`{"wht": 0.0,}`,
+		}
+		for _, body := range bodies {
+			var req taxHandler.TaxRequest
+			assert.Equal(t, strictjson.Unmarshal([]byte(body), &req), doc.Validate(schema, []byte(body)), body)
+		}
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		schema := doc.Paths["/admin/deductions/personal"]["post"].RequestBody.Content[echo.MIMEApplicationJSON].Schema
+
+		err := doc.Validate(schema, []byte(`{"amount": }`))
+
+		expected := apperror.New(apperror.InvalidRequest, "", "request body must be valid JSON").WithDetail("offset", int64(12))
+		assert.Equal(t, expected, err)
+	})
+}
+
+func asErrors(err error) apperror.Errors {
+	if errs, ok := err.(apperror.Errors); ok {
+		return errs
+	}
+	return apperror.Errors{apperror.As(err, apperror.InvalidRequest)}
+}
+
+func TestMiddleware(t *testing.T) {
+	v1, v2 := router.V1(), router.V2()
+	doc := Generate(v1, v2)
+	validate := Middleware(doc, "/tax/calculations", "/admin/*")
+	v1.Middleware = append(v1.Middleware, validate)
+	v2.Middleware = append(v2.Middleware, validate)
+
+	e := echo.New()
+	adminAuth := middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
+		return username == "admin" && password == "secret", nil
+	})
+	router.Register(e, adminAuth, v1, v2)
+
+	post := func(path, body string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if admin {
+			req.SetBasicAuth("admin", "secret")
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		rec := post("/v2/tax/calculations", `{"totalIncome": 500000.0, "wht": 0.0, "allowances": []}`, false)
+
+		assert.Equal(t, http.StatusOK, rec.Code, "Handler should read the body")
+		assert.Contains(t, rec.Body.String(), `"tax":29000`)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		rec := post("/tax/calculations", `{"totalincome": 500000.0, "wht": 0.0}`, false)
+
+		var res apperror.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, apperror.ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, apperror.UnknownField, res.Code)
+		assert.Equal(t, "totalincome", res.Field)
+	})
+
+	t.Run("AdminCredentialsFirst", func(t *testing.T) {
+		rec := post("/admin/deductions/k-receipt", `{"amount": "50000"}`, false)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, "Should check credentials before the body")
+
+		rec = post("/admin/deductions/k-receipt", `{"amount": "50000"}`, true)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "Should validate the body")
+		assert.Contains(t, rec.Body.String(), `"code":"invalid_type"`)
+	})
+
+	t.Run("NotValidated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v2/tax/calculations/reverse", bytes.NewBufferString(`{"note": ""}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/v2/tax/calculations/reverse")
+
+		called := false
+		err := validate(func(c echo.Context) error {
+			called = true
+			return nil
+		})(c)
+
+		assert.NoError(t, err)
+		assert.True(t, called, "Reverse should not be validated")
+	})
+}
+
+func TestMatches(t *testing.T) {
+	paths := []string{"/tax/calculations", "/admin/*"}
+
+	assert.True(t, matches("/tax/calculations", paths))
+	assert.True(t, matches("/v2/tax/calculations", paths))
+	assert.True(t, matches("/v1/admin/deductions/personal", paths))
+	assert.False(t, matches("/tax/calculations/reverse", paths))
+	assert.False(t, matches("/administrator", paths))
+}
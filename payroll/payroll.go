@@ -0,0 +1,122 @@
+package payroll
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"math"
+)
+
+type Bonus struct {
+	Month  int     `json:"month" required:"true"`
+	Amount float64 `json:"amount" required:"true"`
+}
+
+type Plan struct {
+	MonthlySalary float64                `json:"monthlySalary" required:"true"`
+	StartMonth    int                    `json:"startMonth"`
+	Bonuses       []Bonus                `json:"bonuses"`
+	Allowances    []calculator.Allowance `json:"allowances"`
+}
+
+type MonthlyWithholding struct {
+	Month       int     `json:"month"`
+	Salary      float64 `json:"salary"`
+	Bonus       float64 `json:"bonus"`
+	Withholding float64 `json:"withholding"`
+}
+
+type Reconciliation struct {
+	TotalIncome float64 `json:"totalIncome"`
+	AnnualTax   float64 `json:"annualTax"`
+	Withheld    float64 `json:"withheld"`
+	Difference  float64 `json:"difference"`
+}
+
+type Schedule struct {
+	Months         []MonthlyWithholding `json:"months"`
+	Reconciliation Reconciliation       `json:"reconciliation"`
+}
+
+// CalculateSchedule works out the monthly withholding of a salary plan with
+// the annualised method. The salary from the start month to December is taxed
+// as 40(1) income for the year and the tax is spread evenly over those
+// months. The extra tax a bonus adds is withheld in full in its month.
+// Withholding is rounded to satang, so the year-end reconciliation shows what
+// is left to pay (positive) or to refund (negative).
+func CalculateSchedule(plan Plan) (Schedule, error) {
+	startMonth := plan.StartMonth
+	if startMonth == 0 {
+		startMonth = 1
+	}
+	if startMonth < 1 || startMonth > 12 {
+		return Schedule{}, apperror.New(apperror.OutOfRange, "startMonth", "startMonth must be between 1 and 12")
+	}
+	if plan.MonthlySalary < 0 {
+		return Schedule{}, apperror.New(apperror.OutOfRange, "monthlySalary", "monthlySalary must be greater than or equal to 0")
+	}
+
+	bonuses := map[int]float64{}
+	for i, bonus := range plan.Bonuses {
+		if bonus.Month < startMonth || bonus.Month > 12 {
+			return Schedule{}, apperror.New(apperror.OutOfRange, apperror.Index("bonuses", i, "month"), "bonus month must be between startMonth and 12")
+		}
+		if bonus.Amount < 0 {
+			return Schedule{}, apperror.New(apperror.OutOfRange, apperror.Index("bonuses", i, "amount"), "bonus amount must be greater than or equal to 0")
+		}
+		bonuses[bonus.Month] += bonus.Amount
+	}
+
+	months := 12 - startMonth + 1
+	salaryIncome := plan.MonthlySalary * float64(months)
+	salaryTax, err := annualTax(salaryIncome, plan.Allowances)
+	if err != nil {
+		return Schedule{}, err
+	}
+	monthlyTax := roundSatang(salaryTax / float64(months))
+
+	schedule := Schedule{Months: []MonthlyWithholding{}}
+	totalIncome := salaryIncome
+	taxBeforeBonus := salaryTax
+	withheld := 0.0
+	for month := startMonth; month <= 12; month++ {
+		withholding := MonthlyWithholding{
+			Month:       month,
+			Salary:      plan.MonthlySalary,
+			Bonus:       bonuses[month],
+			Withholding: monthlyTax,
+		}
+		if bonuses[month] > 0 {
+			totalIncome += bonuses[month]
+			taxWithBonus, err := annualTax(totalIncome, plan.Allowances)
+			if err != nil {
+				return Schedule{}, err
+			}
+			withholding.Withholding += roundSatang(taxWithBonus - taxBeforeBonus)
+			taxBeforeBonus = taxWithBonus
+		}
+		withheld += withholding.Withholding
+		schedule.Months = append(schedule.Months, withholding)
+	}
+
+	schedule.Reconciliation = Reconciliation{
+		TotalIncome: totalIncome,
+		AnnualTax:   taxBeforeBonus,
+		Withheld:    roundSatang(withheld),
+		Difference:  roundSatang(taxBeforeBonus - withheld),
+	}
+	return schedule, nil
+}
+
+// annualTax is the tax on a year of salary, taxed as 40(1) income.
+func annualTax(salary float64, allowances []calculator.Allowance) (float64, error) {
+	incomes := []calculator.Income{{IncomeType: "40(1)", Amount: salary}}
+	result, err := calculator.CalculateTaxFromIncomes(incomes, nil, 0.0, allowances)
+	if err != nil {
+		return 0.0, err
+	}
+	return result.Tax, nil
+}
+
+func roundSatang(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
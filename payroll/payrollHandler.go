@@ -0,0 +1,23 @@
+package payroll
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+func PayrollHandler(c echo.Context) error {
+	var plan Plan
+	err := strictjson.Bind(c, &plan)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	schedule, err := CalculateSchedule(plan)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, schedule)
+}
@@ -0,0 +1,96 @@
+package payroll
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateSchedule(t *testing.T) {
+	t.Run("FullYear", func(t *testing.T) {
+		plan := Plan{MonthlySalary: 50000.0, StartMonth: 1}
+
+		schedule, err := CalculateSchedule(plan)
+
+		expectedReconciliation := Reconciliation{
+			TotalIncome: 600000.0,
+			AnnualTax:   29000.0,
+			Withheld:    29000.04,
+			Difference:  -0.04,
+		}
+
+		assert.Len(t, schedule.Months, 12, "Should be 12 months")
+		assert.Equal(t, MonthlyWithholding{Month: 1, Salary: 50000.0, Withholding: 2416.67}, schedule.Months[0])
+		assert.Equal(t, expectedReconciliation, schedule.Reconciliation, "Wrong reconciliation")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("BonusWithheldInItsMonth", func(t *testing.T) {
+		plan := Plan{
+			MonthlySalary: 50000.0,
+			Bonuses:       []Bonus{{Month: 12, Amount: 100000.0}},
+		}
+
+		schedule, err := CalculateSchedule(plan)
+
+		assert.Equal(t, MonthlyWithholding{Month: 12, Salary: 50000.0, Bonus: 100000.0, Withholding: 14416.67}, schedule.Months[11])
+		assert.Equal(t, 41000.0, schedule.Reconciliation.AnnualTax, "Annual tax should be 41000.0")
+		assert.Equal(t, -0.04, schedule.Reconciliation.Difference, "Difference should be -0.04")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("MidYearHire", func(t *testing.T) {
+		plan := Plan{
+			MonthlySalary: 100000.0,
+			StartMonth:    7,
+			Allowances:    []calculator.Allowance{{AllowanceType: "donation", Amount: 40000.0}},
+		}
+
+		schedule, err := CalculateSchedule(plan)
+
+		// 600,000 - 100,000 (expense) - 60,000 (personal) - 40,000 (donation) = 400,000
+		assert.Len(t, schedule.Months, 6, "Should be 6 months")
+		assert.Equal(t, 7, schedule.Months[0].Month, "Should start in July")
+		assert.Equal(t, 25000.0, schedule.Reconciliation.AnnualTax, "Annual tax should be 25000.0")
+		assert.Equal(t, -0.02, schedule.Reconciliation.Difference, "Difference should be -0.02")
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("BonusBeforeStartMonth", func(t *testing.T) {
+		plan := Plan{MonthlySalary: 50000.0, StartMonth: 7, Bonuses: []Bonus{{Month: 3, Amount: 10000.0}}}
+
+		_, err := CalculateSchedule(plan)
+
+		assert.Equal(t, "bonus month must be between startMonth and 12", err.Error(), "Should be error")
+	})
+}
+
+func TestPayrollHandler(t *testing.T) {
+	reqJSON := `{"monthlySalary": 50000.0, "bonuses": [{"month": 12, "amonut": 100000.0}]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/payroll", bytes.NewBufferString(reqJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	err := PayrollHandler(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res apperror.Problem
+	err = json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	expectedErrors := []apperror.ProblemError{
+		{Code: apperror.UnknownField, Field: "bonuses[0].amonut", Detail: "bonuses[0].amonut is not a known field"},
+		{Code: apperror.MissingField, Field: "bonuses[0].amount", Detail: "bonuses[0].amount is required"},
+	}
+	assert.Equal(t, apperror.ValidationFailed, res.Code)
+	assert.Equal(t, expectedErrors, res.Errors)
+}
@@ -0,0 +1,104 @@
+// Package router mounts the handlers of each API version under its prefix,
+// so the response format can change in a new version while clients of an
+// old one keep working. Old versions can be marked deprecated, and their
+// responses then carry the Deprecation, Sunset and Link headers.
+package router
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Route struct {
+	Method  string
+	Path    string
+	Handler echo.HandlerFunc
+
+	// Summary, Request and Responses describe the route in the API
+	// document. Request and Responses are values of the body types, with one
+	// response for each shape the route can answer with. File is the form
+	// field of a route taking a multipart file upload instead of JSON.
+	Summary   string
+	Request   interface{}
+	Responses []interface{}
+	File      string
+	// Consumes are the content types of a request body that is not JSON, and
+	// Produces the content type of the responses when they are not JSON.
+	Consumes []string
+	Produces string
+}
+
+type Version struct {
+	Name string
+	// Root also serves the routes without the version prefix, for clients
+	// from before the API was versioned.
+	Root   bool
+	Routes []Route
+	// Admin routes are served under /admin and need admin credentials.
+	Admin []Route
+	// Middleware runs on every route of the version, after the admin
+	// credentials are checked.
+	Middleware []echo.MiddlewareFunc
+
+	// Deprecation is when the version was deprecated, zero if it is not.
+	Deprecation time.Time
+	// Sunset is when the version will be removed, zero if not planned.
+	Sunset time.Time
+	// Successor is the name of the version replacing this one.
+	Successor string
+}
+
+// Deprecate returns the version deprecated at deprecation in favour of
+// successor, to be removed at sunset.
+func (v Version) Deprecate(deprecation, sunset time.Time, successor string) Version {
+	v.Deprecation = deprecation
+	v.Sunset = sunset
+	v.Successor = successor
+	return v
+}
+
+// Register adds the routes of the versions to e. adminAuth guards the admin
+// routes.
+func Register(e *echo.Echo, adminAuth echo.MiddlewareFunc, versions ...Version) {
+	for _, v := range versions {
+		prefixes := []string{"/" + v.Name}
+		if v.Root {
+			prefixes = append(prefixes, "")
+		}
+		middleware := []echo.MiddlewareFunc{}
+		if !v.Deprecation.IsZero() {
+			middleware = append(middleware, deprecation(v))
+		}
+		middleware = append(middleware, v.Middleware...)
+
+		for _, prefix := range prefixes {
+			for _, route := range v.Routes {
+				e.Add(route.Method, prefix+route.Path, route.Handler, middleware...)
+			}
+			for _, route := range v.Admin {
+				e.Add(route.Method, prefix+"/admin"+route.Path, route.Handler, append([]echo.MiddlewareFunc{adminAuth}, middleware...)...)
+			}
+		}
+	}
+}
+
+// deprecation sets the headers of RFC 9745 and RFC 8594 on the responses of
+// a deprecated version, with a link to the same route in its successor.
+func deprecation(v Version) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			header.Set("Deprecation", "@"+strconv.FormatInt(v.Deprecation.Unix(), 10))
+			if !v.Sunset.IsZero() {
+				header.Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if v.Successor != "" {
+				header.Set("Link", "</"+v.Successor+strings.TrimPrefix(c.Path(), "/"+v.Name)+`>; rel="successor-version"`)
+			}
+			return next(c)
+		}
+	}
+}
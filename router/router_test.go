@@ -0,0 +1,78 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+func newServer() *echo.Echo {
+	e := echo.New()
+	adminAuth := middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
+		return username == "admin" && password == "secret", nil
+	})
+	deprecation := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)
+	Register(e, adminAuth, V1().Deprecate(deprecation, sunset, "v2"), V2())
+	return e
+}
+
+func post(e *echo.Echo, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRegister(t *testing.T) {
+	e := newServer()
+	reqJSON := `{"totalIncome": 500000.0, "wht": 40000.0, "allowances": []}`
+
+	t.Run("V1", func(t *testing.T) {
+		for _, path := range []string{"/tax/calculations", "/v1/tax/calculations"} {
+			rec := post(e, path, reqJSON)
+
+			assert.Equal(t, http.StatusOK, rec.Code, "Wrong status of %s", path)
+			assert.Contains(t, rec.Body.String(), `"taxlevel"`, "Should be v1 response of %s", path)
+			assert.Contains(t, rec.Body.String(), `{"taxRefund":11000,`, "Should be v1 refund of %s", path)
+			assert.Equal(t, "@1767225600", rec.Header().Get("Deprecation"), "Wrong Deprecation of %s", path)
+			assert.Equal(t, "Thu, 31 Dec 2026 00:00:00 GMT", rec.Header().Get("Sunset"), "Wrong Sunset of %s", path)
+			assert.Equal(t, `</v2/tax/calculations>; rel="successor-version"`, rec.Header().Get("Link"), "Wrong Link of %s", path)
+		}
+	})
+
+	t.Run("V2", func(t *testing.T) {
+		rec := post(e, "/v2/tax/calculations", reqJSON)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"taxLevel"`, "Should be v2 response")
+		assert.Contains(t, rec.Body.String(), `{"tax":0,"taxRefund":11000,`, "Should be v2 refund")
+		assert.Empty(t, rec.Header().Get("Deprecation"), "Should not be deprecated")
+	})
+
+	t.Run("SharedRoute", func(t *testing.T) {
+		rec := post(e, "/v2/tax/calculations/reverse", `{"target": "net", "amount": 471000.0, "allowances": []}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		rec := post(e, "/v2/admin/deductions/k-receipt", `{"amount": 50000.0}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, "Should need credentials")
+
+		req := httptest.NewRequest(http.MethodPost, "/v2/admin/deductions/k-receipt", bytes.NewBufferString(`{"amount": 50000.0}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth("admin", "secret")
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, "Should be allowed")
+	})
+}
@@ -0,0 +1,157 @@
+package router
+
+import (
+	"github.com/TonRat/assessment-tax/admin"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/payroll"
+	"github.com/TonRat/assessment-tax/taxHandler"
+	"github.com/TonRat/assessment-tax/uploadCSV"
+	"net/http"
+)
+
+// V1 is the API as it was before versioning, with tax and taxRefund in
+// different response shapes. It is also served without a prefix.
+func V1() Version {
+	return Version{
+		Name: "v1",
+		Root: true,
+		Routes: append([]Route{
+			{
+				Method:    http.MethodPost,
+				Path:      "/tax/calculations",
+				Handler:   taxHandler.CalculateTaxHandler,
+				Summary:   "Calculate income tax",
+				Request:   taxHandler.TaxRequest{},
+				Responses: []interface{}{taxHandler.TaxResponse{}, taxHandler.TaxRefundRespond{}},
+			},
+			{
+				Method:    http.MethodPost,
+				Path:      "/tax/calculations/upload-csv",
+				Handler:   uploadcsv.UploadCSVHandler,
+				Summary:   "Calculate income tax of every row of a CSV file",
+				File:      "taxFile",
+				Responses: []interface{}{uploadcsv.TaxResponseCSV{}},
+			},
+		}, sharedRoutes()...),
+		Admin: adminRoutes(),
+	}
+}
+
+// V2 has one response shape for tax and refund.
+func V2() Version {
+	return Version{
+		Name: "v2",
+		Routes: append([]Route{
+			{
+				Method:    http.MethodPost,
+				Path:      "/tax/calculations",
+				Handler:   taxHandler.CalculateTaxV2Handler,
+				Summary:   "Calculate income tax",
+				Request:   taxHandler.TaxRequest{},
+				Responses: []interface{}{taxHandler.TaxResponseV2{}},
+			},
+			{
+				Method:    http.MethodPost,
+				Path:      "/tax/calculations/upload-csv",
+				Handler:   uploadcsv.UploadCSVV2Handler,
+				Summary:   "Calculate income tax of every row of a CSV file",
+				File:      "taxFile",
+				Responses: []interface{}{uploadcsv.TaxResponseCSVV2{}},
+			},
+		}, sharedRoutes()...),
+		Admin: adminRoutes(),
+	}
+}
+
+// sharedRoutes have the same request and response in every version.
+func sharedRoutes() []Route {
+	return []Route{
+		{
+			Method:    http.MethodPost,
+			Path:      "/tax/calculations/batch",
+			Handler:   taxHandler.BatchCalculateTaxHandler,
+			Summary:   "Calculate income tax of many requests",
+			Request:   []taxHandler.BatchItem{},
+			Responses: []interface{}{taxHandler.BatchResponse{}},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/tax/calculations/stream",
+			Handler:   taxHandler.StreamCalculateTaxHandler,
+			Summary:   "Calculate income tax of every line of NDJSON or CSV as it is read",
+			Request:   taxHandler.StreamItem{},
+			Responses: []interface{}{taxHandler.StreamLine{}, taxHandler.StreamSummaryLine{}},
+			Consumes:  []string{taxHandler.MIMEApplicationNDJSON, taxHandler.MIMETextCSV},
+			Produces:  taxHandler.MIMEApplicationNDJSON,
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/tax/calculations/reverse",
+			Handler:   taxHandler.ReverseCalculateHandler,
+			Summary:   "Find the income that gives a net income or tax",
+			Request:   taxHandler.ReverseRequest{},
+			Responses: []interface{}{taxHandler.ReverseResponse{}},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/tax/advice",
+			Handler:   taxHandler.AdviceHandler,
+			Summary:   "Find the allowances left and the tax they save",
+			Request:   taxHandler.TaxRequest{},
+			Responses: []interface{}{taxHandler.AdviceResponse{}},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/tax/scenarios",
+			Handler:   taxHandler.ScenarioHandler,
+			Summary:   "Compare the tax of scenarios with a base calculation",
+			Request:   taxHandler.ScenarioRequest{},
+			Responses: []interface{}{taxHandler.ScenarioResponse{}},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/tax/household",
+			Handler:   taxHandler.HouseholdHandler,
+			Summary:   "Compare the filing options of a married couple",
+			Request:   taxHandler.HouseholdRequest{},
+			Responses: []interface{}{calculator.HouseholdResult{}},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/tax/payroll",
+			Handler:   payroll.PayrollHandler,
+			Summary:   "Plan the monthly withholding of a salary",
+			Request:   payroll.Plan{},
+			Responses: []interface{}{payroll.Schedule{}},
+		},
+	}
+}
+
+func adminRoutes() []Route {
+	return []Route{
+		{
+			Method:    http.MethodPost,
+			Path:      "/deductions/personal",
+			Handler:   admin.PersonalDeductionHandler,
+			Summary:   "Set the personal deduction",
+			Request:   admin.DeductionRequest{},
+			Responses: []interface{}{admin.DeductionResponse{}},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/deductions/k-receipt",
+			Handler:   admin.KReceiptHandler,
+			Summary:   "Set the k-receipt cap",
+			Request:   admin.KReceiptRequest{},
+			Responses: []interface{}{admin.KReceiptResepond{}},
+		},
+		{
+			Method:    http.MethodPost,
+			Path:      "/exchange-rates",
+			Handler:   admin.ExchangeRateHandler,
+			Summary:   "Add or replace exchange rates",
+			Request:   admin.ExchangeRateRequest{},
+			Responses: []interface{}{admin.ExchangeRateResponse{}},
+		},
+	}
+}
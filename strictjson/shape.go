@@ -0,0 +1,135 @@
+package strictjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Kind is the JSON type a value must have.
+type Kind int
+
+const (
+	// Any accepts every value.
+	Any Kind = iota
+	Object
+	Array
+	Number
+	Integer
+	String
+	Boolean
+)
+
+// Shape describes the JSON a value must match, like a Go type or an OpenAPI
+// schema.
+type Shape interface {
+	Kind() Kind
+	// Fields are the names of the known fields of an object, and Field the
+	// shape of one of them, if it is known.
+	Fields() []string
+	Field(name string) (shape Shape, required bool, known bool)
+	// Closed objects reject fields that are not known.
+	Closed() bool
+	// Elem is the shape of the elements of an array.
+	Elem() Shape
+}
+
+// rawMessageType is left to be decoded later, so any value is accepted.
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// typeShape is the JSON that encoding/json decodes into a Go type. Structs
+// are closed, and their fields tagged `required:"true"` are required.
+type typeShape struct {
+	t reflect.Type
+}
+
+func shapeOf(t reflect.Type) typeShape {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return typeShape{t: t}
+}
+
+func (s typeShape) Kind() Kind {
+	if s.t == rawMessageType {
+		return Any
+	}
+	switch s.t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return Object
+	case reflect.Slice, reflect.Array:
+		return Array
+	case reflect.Float32, reflect.Float64:
+		return Number
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Integer
+	case reflect.String:
+		return String
+	case reflect.Bool:
+		return Boolean
+	}
+	return Any
+}
+
+func (s typeShape) Fields() []string {
+	if s.t.Kind() != reflect.Struct {
+		return nil
+	}
+	fs := fields(s.t)
+	names := make([]string, 0, len(fs))
+	for name := range fs {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s typeShape) Field(name string) (Shape, bool, bool) {
+	if s.t.Kind() != reflect.Struct {
+		return nil, false, false
+	}
+	f, ok := fields(s.t)[name]
+	if !ok {
+		return nil, false, false
+	}
+	return shapeOf(f.typ), f.required, true
+}
+
+// Closed is false for maps, which take any field.
+func (s typeShape) Closed() bool {
+	return s.t.Kind() == reflect.Struct
+}
+
+func (s typeShape) Elem() Shape {
+	return shapeOf(s.t.Elem())
+}
+
+type field struct {
+	typ      reflect.Type
+	required bool
+}
+
+// fields are the JSON fields of a struct, including those of embedded
+// structs.
+func fields(t reflect.Type) map[string]field {
+	fs := map[string]field{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			for name, embedded := range fields(f.Type) {
+				fs[name] = embedded
+			}
+			continue
+		}
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fs[name] = field{typ: f.Type, required: f.Tag.Get("required") == "true"}
+	}
+	return fs
+}
@@ -0,0 +1,168 @@
+// Package strictjson binds request bodies that must match the request type
+// exactly. Unlike echo's Bind, which ignores fields it does not know and
+// matches names case-insensitively, a misspelled field like "totalincome", a
+// missing field tagged `required:"true"` or a value of the wrong type is
+// reported with the path of the field. Check takes any Shape, so other
+// descriptions of a body, like an OpenAPI schema, give the same problems.
+package strictjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/labstack/echo/v4"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Bind decodes the request body into v, which must be a pointer to a struct.
+func Bind(c echo.Context, v interface{}) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return apperror.New(apperror.InvalidRequest, "", "request body cannot be read")
+	}
+	return Unmarshal(body, v)
+}
+
+// Unmarshal checks data against the type of v and decodes it into v. All
+// problems are returned at once. An empty body is decoded as {}.
+func Unmarshal(data []byte, v interface{}) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+	value, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	if err := Check(value, shapeOf(reflect.TypeOf(v).Elem())); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return apperror.New(apperror.InvalidRequest, "", err.Error())
+	}
+	return nil
+}
+
+// Decode reads data as a single JSON value, with numbers as json.Number so
+// integers can be told from other numbers. An empty body is read as {}.
+func Decode(data []byte) (interface{}, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, syntaxError(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, apperror.New(apperror.InvalidRequest, "", "request body must be a single JSON value")
+	}
+	return value, nil
+}
+
+func syntaxError(err error) error {
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		return apperror.New(apperror.InvalidRequest, "", "request body must be valid JSON").WithDetail("offset", se.Offset)
+	}
+	return apperror.New(apperror.InvalidRequest, "", "request body must be valid JSON")
+}
+
+// Check checks a value read by Decode against shape and returns every
+// problem at once, with the path of the field of each.
+func Check(value interface{}, shape Shape) error {
+	errs := apperror.Errors{}
+	check(&errs, "", value, shape)
+	return errs.Err()
+}
+
+func check(errs *apperror.Errors, path string, value interface{}, shape Shape) {
+	if shape.Kind() == Any {
+		return
+	}
+	if value == nil {
+		// null is a field that is not given, but a body of null has nothing
+		// to decode
+		if path == "" {
+			*errs = append(*errs, apperror.New(apperror.InvalidType, "", "request body must not be null"))
+		}
+		return
+	}
+
+	switch shape.Kind() {
+	case Object:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*errs = append(*errs, typeError(path, "an object"))
+			return
+		}
+		names := shape.Fields()
+		for name := range object {
+			if _, _, known := shape.Field(name); !known {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			f, required, known := shape.Field(name)
+			v, given := object[name]
+			switch {
+			case !known && shape.Closed():
+				*errs = append(*errs, apperror.New(apperror.UnknownField, join(path, name), join(path, name)+" is not a known field"))
+			case known && required && (!given || v == nil):
+				*errs = append(*errs, apperror.New(apperror.MissingField, join(path, name), join(path, name)+" is required"))
+			case known && given:
+				check(errs, join(path, name), v, f)
+			}
+		}
+	case Array:
+		list, ok := value.([]interface{})
+		if !ok {
+			*errs = append(*errs, typeError(path, "an array"))
+			return
+		}
+		for i, v := range list {
+			check(errs, path+"["+strconv.Itoa(i)+"]", v, shape.Elem())
+		}
+	case Number:
+		if _, ok := value.(json.Number); !ok {
+			*errs = append(*errs, typeError(path, "a number"))
+		}
+	case Integer:
+		n, ok := value.(json.Number)
+		if !ok {
+			*errs = append(*errs, typeError(path, "an integer"))
+			return
+		}
+		if _, err := n.Int64(); err != nil {
+			*errs = append(*errs, typeError(path, "an integer"))
+		}
+	case String:
+		if _, ok := value.(string); !ok {
+			*errs = append(*errs, typeError(path, "a string"))
+		}
+	case Boolean:
+		if _, ok := value.(bool); !ok {
+			*errs = append(*errs, typeError(path, "a boolean"))
+		}
+	}
+}
+
+func typeError(path, kind string) *apperror.Error {
+	if path == "" {
+		return apperror.New(apperror.InvalidType, "", "request body must be "+kind)
+	}
+	return apperror.New(apperror.InvalidType, path, path+" must be "+kind)
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,118 @@
+package strictjson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TonRat/assessment-tax/apperror"
+)
+
+type item struct {
+	Type   string  `json:"type" required:"true"`
+	Amount float64 `json:"amount" required:"true"`
+}
+
+type embedded struct {
+	ID string `json:"id,omitempty"`
+}
+
+type request struct {
+	embedded
+	Total  float64  `json:"total"`
+	Count  int      `json:"count"`
+	Items  []item   `json:"items"`
+	Limit  *float64 `json:"limit"`
+	Active bool     `json:"active"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		var r request
+		err := Unmarshal([]byte(`{"id": "a", "total": 1.5, "count": 2, "items": [{"type": "x", "amount": 1}], "limit": null, "active": true}`), &r)
+
+		expected := request{embedded: embedded{ID: "a"}, Total: 1.5, Count: 2, Items: []item{{Type: "x", Amount: 1.0}}, Active: true}
+		assert.Nil(t, err, "Should not be error")
+		assert.Equal(t, expected, r, "Wrong request")
+	})
+
+	t.Run("EmptyBody", func(t *testing.T) {
+		var r request
+		err := Unmarshal([]byte(""), &r)
+
+		assert.Nil(t, err, "Should not be error")
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		var r request
+		err := Unmarshal([]byte(`{"Total": 1.0, "items": [{"type": "x", "amount": 1, "note": ""}]}`), &r)
+
+		expected := apperror.Errors{
+			apperror.New(apperror.UnknownField, "Total", "Total is not a known field"),
+			apperror.New(apperror.UnknownField, "items[0].note", "items[0].note is not a known field"),
+		}
+		assert.Equal(t, expected, err, "Should be unknown fields")
+	})
+
+	t.Run("MissingField", func(t *testing.T) {
+		var r request
+		err := Unmarshal([]byte(`{"items": [{"type": "x", "amount": null}]}`), &r)
+
+		expected := apperror.New(apperror.MissingField, "items[0].amount", "items[0].amount is required")
+		assert.Equal(t, expected, err, "Should be missing field")
+	})
+
+	t.Run("WrongType", func(t *testing.T) {
+		var r request
+		err := Unmarshal([]byte(`{"total": "1", "count": 1.5, "items": {}, "limit": true, "id": 1}`), &r)
+
+		expected := apperror.Errors{
+			apperror.New(apperror.InvalidType, "count", "count must be an integer"),
+			apperror.New(apperror.InvalidType, "id", "id must be a string"),
+			apperror.New(apperror.InvalidType, "items", "items must be an array"),
+			apperror.New(apperror.InvalidType, "limit", "limit must be a number"),
+			apperror.New(apperror.InvalidType, "total", "total must be a number"),
+		}
+		assert.Equal(t, expected, err, "Should be wrong types")
+	})
+
+	t.Run("NotAnObject", func(t *testing.T) {
+		var r request
+		err := Unmarshal([]byte(`[]`), &r)
+
+		expected := apperror.New(apperror.InvalidType, "", "request body must be an object")
+		assert.Equal(t, expected, err, "Should be wrong type")
+	})
+
+	t.Run("Null", func(t *testing.T) {
+		var r request
+		err := Unmarshal([]byte(`null`), &r)
+
+		expected := apperror.New(apperror.InvalidType, "", "request body must not be null")
+		assert.Equal(t, expected, err, "Should be wrong type")
+	})
+
+	t.Run("RawMessage", func(t *testing.T) {
+		var raw []json.RawMessage
+		err := Unmarshal([]byte(`[{"totl": 1}, 2, null]`), &raw)
+
+		assert.Nil(t, err, "Should not be error")
+		assert.Equal(t, []json.RawMessage{json.RawMessage(`{"totl": 1}`), json.RawMessage(`2`), json.RawMessage(`null`)}, raw)
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		var r request
+		err := Unmarshal([]byte(`{"total": 1,}`), &r)
+
+		expected := apperror.New(apperror.InvalidRequest, "", "request body must be valid JSON").WithDetail("offset", int64(13))
+		assert.Equal(t, expected, err, "Should be syntax error")
+	})
+
+	t.Run("TrailingValue", func(t *testing.T) {
+		var r request
+		err := Unmarshal([]byte(`{"total": 1} {}`), &r)
+
+		assert.Equal(t, "request body must be a single JSON value", err.Error(), "Should be error")
+	})
+}
@@ -0,0 +1,81 @@
+package taxHandler
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/i18n"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type AdviceResponse struct {
+	Tax     float64                      `json:"tax"`
+	TaxRate calculator.TaxRate           `json:"taxRate"`
+	Advice  []calculator.AllowanceAdvice `json:"advice"`
+}
+
+func AdviceHandler(c echo.Context) error {
+	var t TaxRequest
+	err := strictjson.Bind(c, &t)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+	_, errs := validateRequest(t)
+	errs = append(errs, validateAdviceRequest(t)...)
+	if err := errs.Err(); err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	var result calculator.IncomeTaxResult
+	if len(t.Incomes) > 0 {
+		if t.TotalIncome != 0 {
+			return apperror.Respond(c, http.StatusBadRequest, apperror.New(apperror.ConflictingFields, "totalIncome", "totalIncome cannot be used together with incomes or investments"))
+		}
+		result, err = calculator.CalculateTaxFromIncomes(t.Incomes, nil, t.WHT, t.Allowances)
+	} else {
+		result, err = calculator.CalculateTaxDetail(t.TotalIncome, t.WHT, t.Allowances)
+	}
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	advice, err := calculator.AdviseAllowances(result.NetIncome, result.Incomes, t.Allowances)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	lang := i18n.Negotiate(c.Request(), c.Response())
+	res := AdviceResponse{Tax: result.Tax, TaxRate: calculator.LocalizeTaxRate(lang, result.TaxRate), Advice: advice}
+	return c.JSON(http.StatusOK, res)
+}
+
+// validateAdviceRequest rejects the fields of TaxRequest that advice cannot
+// take into account, rather than advising as if they were not given.
+func validateAdviceRequest(t TaxRequest) apperror.Errors {
+	errs := apperror.Errors{}
+	switch t.Period {
+	case "", "annual":
+	case "half-year":
+		errs = append(errs, apperror.New(apperror.ConflictingFields, "period", "advice is only given for the annual period"))
+	default:
+		errs = append(errs, apperror.New(apperror.UnknownType, "period", "period must be annual or half-year"))
+	}
+	if len(t.Investments) > 0 {
+		errs = append(errs, apperror.New(apperror.ConflictingFields, "investments", "investments are not supported by advice"))
+	}
+	for _, field := range []struct {
+		name  string
+		given bool
+	}{
+		{name: "foreignIncomes", given: len(t.ForeignIncomes) > 0},
+		{name: "halfYearTax", given: t.HalfYearTax != 0},
+		{name: "paymentDate", given: t.PaymentDate != ""},
+		{name: "installments", given: t.Installments},
+	} {
+		if field.given {
+			errs = append(errs, apperror.New(apperror.ConflictingFields, field.name, field.name+" cannot be used with advice"))
+		}
+	}
+	return errs
+}
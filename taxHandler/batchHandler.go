@@ -0,0 +1,138 @@
+package taxHandler
+
+import (
+	"encoding/json"
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/i18n"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"runtime"
+	"sync"
+)
+
+// MaxBatchSize is the most calculations of one batch request.
+const MaxBatchSize = 1000
+
+// BatchConcurrency is how many calculations of a batch run at the same time.
+var BatchConcurrency = runtime.GOMAXPROCS(0)
+
+// BatchItem is a TaxRequest with an ID chosen by the client to match the
+// result.
+type BatchItem struct {
+	ID string `json:"id" required:"true"`
+	TaxRequest
+}
+
+// BatchResult has the result of a calculation in the v2 shape, or the
+// problem that stopped it. The fields of a problem decoding the item are
+// relative to the item.
+type BatchResult struct {
+	ID     string            `json:"id"`
+	Result *TaxResponseV2    `json:"result,omitempty"`
+	Error  *apperror.Problem `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Results   []BatchResult `json:"results"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+}
+
+func BatchCalculateTaxHandler(c echo.Context) error {
+	var raw []json.RawMessage
+	err := strictjson.Bind(c, &raw)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+	items, decodeErrs := decodeBatch(raw)
+	err = validateBatch(items)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	lang := i18n.Negotiate(c.Request(), c.Response())
+	explain := c.QueryParam("explain") == "true"
+
+	// each calculation writes only its own result, so results stay in the
+	// order of the request
+	results := make([]BatchResult, len(items))
+	workers := make(chan struct{}, max(BatchConcurrency, 1))
+	var wg sync.WaitGroup
+	for i := range items {
+		workers <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			if decodeErrs[i] != nil {
+				results[i] = itemError(items[i].ID, decodeErrs[i], lang)
+				return
+			}
+			results[i] = calculateItem(items[i], explain, lang)
+		}(i)
+	}
+	wg.Wait()
+
+	res := BatchResponse{Results: results}
+	for _, result := range results {
+		if result.Error != nil {
+			res.Failed++
+		} else {
+			res.Succeeded++
+		}
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// decodeBatch decodes each item on its own, so an item that cannot be decoded
+// fails alone. Its id is still read when it can be, to match the problem.
+func decodeBatch(raw []json.RawMessage) ([]BatchItem, []error) {
+	items := make([]BatchItem, len(raw))
+	errs := make([]error, len(raw))
+	for i, data := range raw {
+		errs[i] = strictjson.Unmarshal(data, &items[i])
+		if errs[i] != nil {
+			var id struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal(data, &id)
+			items[i] = BatchItem{ID: id.ID}
+		}
+	}
+	return items, errs
+}
+
+// validateBatch checks the size of the batch and that the ids given are
+// unique. Items without an id already fail on their own.
+func validateBatch(items []BatchItem) error {
+	if len(items) == 0 || len(items) > MaxBatchSize {
+		return apperror.New(apperror.OutOfRange, "", "batch must have 1 to 1000 calculations").WithDetail("size", len(items))
+	}
+	errs := apperror.Errors{}
+	seen := map[string]bool{}
+	for i, item := range items {
+		if item.ID == "" {
+			continue
+		}
+		if seen[item.ID] {
+			errs = append(errs, apperror.New(apperror.ConflictingFields, apperror.Index("", i, "id"), "id is given more than once"))
+		}
+		seen[item.ID] = true
+	}
+	return errs.Err()
+}
+
+func calculateItem(item BatchItem, explain bool, lang string) BatchResult {
+	res, err := calculateTax(item.TaxRequest, explain)
+	if err != nil {
+		return itemError(item.ID, err, lang)
+	}
+	result := responseV2(localize(lang, res))
+	return BatchResult{ID: item.ID, Result: &result}
+}
+
+func itemError(id string, err error, lang string) BatchResult {
+	problem := apperror.NewProblem(http.StatusBadRequest, err).Localize(lang)
+	return BatchResult{ID: id, Error: &problem}
+}
@@ -0,0 +1,45 @@
+package taxHandler
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type HouseholdRequest struct {
+	Spouse1 calculator.Spouse `json:"spouse1" required:"true"`
+	Spouse2 calculator.Spouse `json:"spouse2" required:"true"`
+}
+
+func HouseholdHandler(c echo.Context) error {
+	var h HouseholdRequest
+	err := strictjson.Bind(c, &h)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	errs := apperror.Errors{}
+	for _, spouse := range []struct {
+		name       string
+		allowances []calculator.Allowance
+	}{
+		{name: "spouse1", allowances: h.Spouse1.Allowances},
+		{name: "spouse2", allowances: h.Spouse2.Allowances},
+	} {
+		for _, e := range calculator.ValidateAllowances(spouse.allowances) {
+			errs = append(errs, apperror.As(apperror.Prefix(spouse.name, e), apperror.InvalidRequest))
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	result, err := calculator.CalculateHousehold(h.Spouse1, h.Spouse2)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,24 @@
+package taxHandler
+
+import "github.com/TonRat/assessment-tax/calculator"
+
+// localize renders the labels and explanation of a calculateTax result in
+// lang.
+func localize(lang string, res interface{}) interface{} {
+	switch r := res.(type) {
+	case TaxResponse:
+		r.TaxLevels = calculator.LocalizeTaxLevels(lang, r.TaxLevels)
+		r.TaxRate = calculator.LocalizeTaxRate(lang, r.TaxRate)
+		r.TaxMethod = calculator.LocalizeTaxMethod(lang, r.TaxMethod)
+		r.Explanation = calculator.LocalizeSteps(lang, r.Explanation)
+		return r
+	case TaxRefundRespond:
+		r.TaxLevels = calculator.LocalizeTaxLevels(lang, r.TaxLevels)
+		r.TaxRate = calculator.LocalizeTaxRate(lang, r.TaxRate)
+		r.TaxMethod = calculator.LocalizeTaxMethod(lang, r.TaxMethod)
+		r.Explanation = calculator.LocalizeSteps(lang, r.Explanation)
+		return r
+	default:
+		return res
+	}
+}
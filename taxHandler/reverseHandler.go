@@ -0,0 +1,54 @@
+package taxHandler
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/i18n"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type ReverseRequest struct {
+	Target     string                 `json:"target" required:"true"`
+	Amount     float64                `json:"amount" required:"true"`
+	Allowances []calculator.Allowance `json:"allowances"`
+}
+
+type ReverseResponse struct {
+	TotalIncome float64               `json:"totalIncome"`
+	Tax         float64               `json:"tax"`
+	NetIncome   float64               `json:"netIncome"`
+	TaxLevels   []calculator.TaxLevel `json:"taxlevel"`
+	TaxRate     calculator.TaxRate    `json:"taxRate"`
+	Explanation []calculator.Step     `json:"explanation"`
+}
+
+func ReverseCalculateHandler(c echo.Context) error {
+	var r ReverseRequest
+	err := strictjson.Bind(c, &r)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	totalIncome, err := calculator.SolveIncome(r.Target, r.Amount, r.Allowances)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	result, err := calculator.CalculateTaxDetail(totalIncome, 0.0, r.Allowances)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	lang := i18n.Negotiate(c.Request(), c.Response())
+	res := ReverseResponse{
+		TotalIncome: totalIncome,
+		Tax:         result.Tax,
+		NetIncome:   totalIncome - result.Tax,
+		TaxLevels:   calculator.LocalizeTaxLevels(lang, result.TaxLevels),
+		TaxRate:     calculator.LocalizeTaxRate(lang, result.TaxRate),
+		Explanation: calculator.LocalizeSteps(lang, result.Trace),
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,191 @@
+package taxHandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/i18n"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sort"
+)
+
+type ScenarioRequest struct {
+	Base      TaxRequest         `json:"base"`
+	Scenarios []ScenarioOverride `json:"scenarios"`
+}
+
+// ScenarioOverride replaces the base fields that are given. Allowances are
+// replaced by type, so a k-receipt of 0 removes the base k-receipt.
+type ScenarioOverride struct {
+	Name        string                  `json:"name"`
+	TotalIncome *float64                `json:"totalIncome"`
+	WHT         *float64                `json:"wht"`
+	Allowances  []calculator.Allowance  `json:"allowances"`
+	Incomes     []calculator.Income     `json:"incomes"`
+	Investments []calculator.Investment `json:"investments"`
+}
+
+type FieldDiff struct {
+	Field    string      `json:"field"`
+	Base     interface{} `json:"base"`
+	Scenario interface{} `json:"scenario"`
+	Change   *float64    `json:"change,omitempty"`
+}
+
+type ScenarioResult struct {
+	Name   string      `json:"name"`
+	Result interface{} `json:"result"`
+	Diff   []FieldDiff `json:"diff"`
+}
+
+type ScenarioResponse struct {
+	Base      interface{}      `json:"base"`
+	Scenarios []ScenarioResult `json:"scenarios"`
+}
+
+func ScenarioHandler(c echo.Context) error {
+	var r ScenarioRequest
+	err := strictjson.Bind(c, &r)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	lang := i18n.Negotiate(c.Request(), c.Response())
+	base, err := calculateTax(r.Base, false)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, apperror.Prefix("base", err))
+	}
+	base = localize(lang, base)
+
+	names := map[string]bool{}
+	scenarios := []ScenarioResult{}
+	for i, scenario := range r.Scenarios {
+		if scenario.Name == "" || names[scenario.Name] {
+			return apperror.Respond(c, http.StatusBadRequest, apperror.New(apperror.InvalidRequest, apperror.Index("scenarios", i, "name"), "scenario name must be unique and not empty"))
+		}
+		names[scenario.Name] = true
+
+		res, err := calculateTax(applyOverride(r.Base, scenario), false)
+		if err != nil {
+			return apperror.Respond(c, http.StatusBadRequest, apperror.Prefix(scenario.Name, err))
+		}
+		res = localize(lang, res)
+		diff, err := diffFields(base, res)
+		if err != nil {
+			return apperror.Respond(c, http.StatusInternalServerError, err)
+		}
+		scenarios = append(scenarios, ScenarioResult{Name: scenario.Name, Result: res, Diff: diff})
+	}
+
+	return c.JSON(http.StatusOK, ScenarioResponse{Base: base, Scenarios: scenarios})
+}
+
+func applyOverride(base TaxRequest, scenario ScenarioOverride) TaxRequest {
+	t := base
+	if scenario.TotalIncome != nil {
+		t.TotalIncome = *scenario.TotalIncome
+	}
+	if scenario.WHT != nil {
+		t.WHT = *scenario.WHT
+	}
+	if scenario.Incomes != nil {
+		t.Incomes = scenario.Incomes
+	}
+	if scenario.Investments != nil {
+		t.Investments = scenario.Investments
+	}
+
+	t.Allowances = append([]calculator.Allowance{}, base.Allowances...)
+	for _, override := range scenario.Allowances {
+		replaced := false
+		for i := range t.Allowances {
+			if t.Allowances[i].AllowanceType == override.AllowanceType {
+				t.Allowances[i].Amount = override.Amount
+				replaced = true
+			}
+		}
+		if !replaced {
+			t.Allowances = append(t.Allowances, override)
+		}
+	}
+	return t
+}
+
+// diffFields compares the JSON of two responses field by field and returns
+// every field that differs, with the change for numbers.
+func diffFields(base, scenario interface{}) ([]FieldDiff, error) {
+	baseFields, err := flattenJSON(base)
+	if err != nil {
+		return nil, err
+	}
+	scenarioFields, err := flattenJSON(scenario)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []string{}
+	for field := range baseFields {
+		fields = append(fields, field)
+	}
+	for field := range scenarioFields {
+		if _, ok := baseFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diff := []FieldDiff{}
+	for _, field := range fields {
+		baseValue, scenarioValue := baseFields[field], scenarioFields[field]
+		if baseValue == scenarioValue {
+			continue
+		}
+		fieldDiff := FieldDiff{Field: field, Base: baseValue, Scenario: scenarioValue}
+		baseNumber, baseOk := baseValue.(float64)
+		scenarioNumber, scenarioOk := scenarioValue.(float64)
+		if baseOk && scenarioOk {
+			change := scenarioNumber - baseNumber
+			fieldDiff.Change = &change
+		}
+		diff = append(diff, fieldDiff)
+	}
+	return diff, nil
+}
+
+func flattenJSON(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	err = json.Unmarshal(b, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	flatten("", decoded, fields)
+	return fields, nil
+}
+
+func flatten(prefix string, v interface{}, fields map[string]interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if prefix == "" {
+				flatten(key, child, fields)
+			} else {
+				flatten(prefix+"."+key, child, fields)
+			}
+		}
+	case []interface{}:
+		for i, child := range value {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), child, fields)
+		}
+	default:
+		fields[prefix] = value
+	}
+}
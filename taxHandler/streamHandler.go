@@ -0,0 +1,163 @@
+package taxHandler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/i18n"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/TonRat/assessment-tax/uploadCSV"
+	"github.com/labstack/echo/v4"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const (
+	MIMEApplicationNDJSON = "application/x-ndjson"
+	MIMETextCSV           = "text/csv"
+)
+
+// MaxStreamLine is the longest NDJSON line that can be read, the same as the
+// longest CSV row.
+const MaxStreamLine = uploadcsv.MaxRowSize
+
+// StreamItem is one NDJSON line of a stream, a TaxRequest with an optional
+// ID chosen by the client.
+type StreamItem struct {
+	ID string `json:"id,omitempty"`
+	TaxRequest
+}
+
+// StreamLine is the result of one input line. Line counts the lines of
+// NDJSON input and the rows of CSV input, with the header as row 1. Result
+// is a TaxResponseV2 for NDJSON input and an uploadcsv.TaxRecordV2 for CSV
+// input.
+type StreamLine struct {
+	Line   int               `json:"line"`
+	ID     string            `json:"id,omitempty"`
+	Result interface{}       `json:"result,omitempty"`
+	Error  *apperror.Problem `json:"error,omitempty"`
+}
+
+type StreamSummary struct {
+	Lines     int `json:"lines"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Error is why the input could not be read to the end.
+	Error *apperror.Problem `json:"error,omitempty"`
+}
+
+// StreamSummaryLine is the last line of a stream.
+type StreamSummaryLine struct {
+	Summary StreamSummary `json:"summary"`
+}
+
+// StreamCalculateTaxHandler reads NDJSON or CSV from the request body and
+// writes the result of each line as NDJSON as soon as it is calculated,
+// followed by a summary line. Neither the input nor the results are held in
+// memory, so the stream can be of any length.
+func StreamCalculateTaxHandler(c echo.Context) error {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	lang := i18n.Negotiate(c.Request(), c.Response())
+	explain := c.QueryParam("explain") == "true"
+
+	var next func() (StreamLine, error)
+	switch mediaType {
+	case MIMEApplicationNDJSON:
+		next = ndjsonLines(c.Request().Body, lang, explain)
+	case MIMETextCSV:
+		reader, err := uploadcsv.NewReader(c.Request().Body, lang, explain)
+		if err != nil {
+			return apperror.Respond(c, http.StatusBadRequest, err)
+		}
+		next = csvLines(reader, lang)
+	default:
+		return apperror.Respond(c, http.StatusUnsupportedMediaType, apperror.New(apperror.InvalidRequest, "", "Content-Type must be application/x-ndjson or text/csv"))
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationNDJSON)
+	c.Response().WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(c.Response())
+
+	summary := StreamSummary{}
+	for {
+		line, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			problem := apperror.NewProblem(http.StatusBadRequest, err).Localize(lang)
+			summary.Error = &problem
+			break
+		}
+
+		summary.Lines++
+		if line.Error != nil {
+			summary.Failed++
+		} else {
+			summary.Succeeded++
+		}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+		c.Response().Flush()
+	}
+	return encoder.Encode(StreamSummaryLine{Summary: summary})
+}
+
+// ndjsonLines calculates the tax of each NDJSON line. Blank lines are
+// skipped.
+func ndjsonLines(r io.Reader, lang string, explain bool) func() (StreamLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxStreamLine)
+	n := 0
+	return func() (StreamLine, error) {
+		for scanner.Scan() {
+			n++
+			data := scanner.Bytes()
+			if len(bytes.TrimSpace(data)) == 0 {
+				continue
+			}
+
+			var item StreamItem
+			if err := strictjson.Unmarshal(data, &item); err != nil {
+				return streamError(n, "", err, lang), nil
+			}
+			res, err := calculateTax(item.TaxRequest, explain)
+			if err != nil {
+				return streamError(n, item.ID, err, lang), nil
+			}
+			return StreamLine{Line: n, ID: item.ID, Result: responseV2(localize(lang, res))}, nil
+		}
+		if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+			return StreamLine{}, apperror.New(apperror.InvalidRequest, "", "line is longer than 1 MiB").WithDetail("line", n+1)
+		}
+		if scanner.Err() != nil {
+			return StreamLine{}, scanner.Err()
+		}
+		return StreamLine{}, io.EOF
+	}
+}
+
+// csvLines calculates the tax of each CSV row.
+func csvLines(reader *uploadcsv.Reader, lang string) func() (StreamLine, error) {
+	return func() (StreamLine, error) {
+		record, err := reader.Read()
+		var rowErrs apperror.Errors
+		if errors.As(err, &rowErrs) {
+			return streamError(reader.Row(), "", rowErrs.Err(), lang), nil
+		}
+		if err != nil {
+			return StreamLine{}, err
+		}
+		return StreamLine{Line: reader.Row(), Result: record}, nil
+	}
+}
+
+func streamError(line int, id string, err error, lang string) StreamLine {
+	problem := apperror.NewProblem(http.StatusBadRequest, err).Localize(lang)
+	return StreamLine{Line: line, ID: id, Error: &problem}
+}
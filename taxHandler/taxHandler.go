@@ -1,47 +1,194 @@
 package taxHandler
 
 import (
+	"github.com/TonRat/assessment-tax/apperror"
 	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/i18n"
+	"github.com/TonRat/assessment-tax/strictjson"
 	"github.com/labstack/echo/v4"
 	"net/http"
 )
 
 type TaxRequest struct {
-	TotalIncome float64                `json:"totalIncome"`
-	WHT         float64                `json:"wht"`
-	Allowances  []calculator.Allowance `json:"allowances"`
+	calculator.Taxpayer
+	TotalIncome    float64                    `json:"totalIncome"`
+	WHT            float64                    `json:"wht" required:"true"`
+	Allowances     []calculator.Allowance     `json:"allowances"`
+	Incomes        []calculator.Income        `json:"incomes"`
+	Investments    []calculator.Investment    `json:"investments"`
+	ForeignIncomes []calculator.ForeignIncome `json:"foreignIncomes"`
+	Period         string                     `json:"period"`
+	HalfYearTax    float64                    `json:"halfYearTax"`
+	PaymentDate    string                     `json:"paymentDate"`
+	Installments   bool                       `json:"installments"`
 }
 
 type TaxResponse struct {
-	Tax       float64               `json:"tax"`
-	TaxLevels []calculator.TaxLevel `json:"taxlevel"`
+	calculator.Taxpayer
+	Tax              float64                          `json:"tax"`
+	TaxLevels        []calculator.TaxLevel            `json:"taxlevel"`
+	TaxRate          calculator.TaxRate               `json:"taxRate"`
+	Incomes          []calculator.IncomeDetail        `json:"incomes,omitempty"`
+	TaxMethod        *calculator.TaxMethod            `json:"taxMethod,omitempty"`
+	Investment       *calculator.InvestmentElection   `json:"investment,omitempty"`
+	ForeignIncomes   []calculator.ForeignIncomeDetail `json:"foreignIncomes,omitempty"`
+	ForeignTaxCredit *calculator.ForeignTaxCredit     `json:"foreignTaxCredit,omitempty"`
+	Period           string                           `json:"period,omitempty"`
+	Payment          *calculator.Payment              `json:"payment,omitempty"`
+	Explanation      []calculator.Step                `json:"explanation,omitempty"`
 }
 type TaxRefundRespond struct {
-	TaxRefund float64               `json:"taxRefund"`
-	TaxLevels []calculator.TaxLevel `json:"taxlevel"`
-}
-
-type Err struct {
-	Message string `json:"message"`
+	calculator.Taxpayer
+	TaxRefund        float64                          `json:"taxRefund"`
+	TaxLevels        []calculator.TaxLevel            `json:"taxlevel"`
+	TaxRate          calculator.TaxRate               `json:"taxRate"`
+	Incomes          []calculator.IncomeDetail        `json:"incomes,omitempty"`
+	TaxMethod        *calculator.TaxMethod            `json:"taxMethod,omitempty"`
+	Investment       *calculator.InvestmentElection   `json:"investment,omitempty"`
+	ForeignIncomes   []calculator.ForeignIncomeDetail `json:"foreignIncomes,omitempty"`
+	ForeignTaxCredit *calculator.ForeignTaxCredit     `json:"foreignTaxCredit,omitempty"`
+	Period           string                           `json:"period,omitempty"`
+	Explanation      []calculator.Step                `json:"explanation,omitempty"`
 }
 
 func CalculateTaxHandler(c echo.Context) error {
 	var t TaxRequest
-	err := c.Bind(&t)
+	err := strictjson.Bind(c, &t)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	res, err := calculateTax(t, c.QueryParam("explain") == "true")
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+	return c.JSON(http.StatusOK, localize(i18n.Negotiate(c.Request(), c.Response()), res))
+}
+
+// calculateTax returns TaxResponse, or TaxRefundRespond when tax is refunded.
+func calculateTax(t TaxRequest, explain bool) (interface{}, error) {
+	taxpayer, errs := validateRequest(t)
+	err := errs.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	var result calculator.IncomeTaxResult
+	fromIncomes := len(t.Incomes) > 0 || len(t.Investments) > 0 || len(t.ForeignIncomes) > 0
+	var foreign []calculator.ForeignIncomeDetail
+	var foreignTaxCredit *calculator.ForeignTaxCredit
+	switch t.Period {
+	case "half-year":
+		if len(t.Incomes) == 0 || t.TotalIncome != 0 || len(t.Investments) > 0 || len(t.ForeignIncomes) > 0 {
+			return nil, apperror.New(apperror.ConflictingFields, "period", "half-year period requires incomes 40(5) to 40(8)")
+		}
+		if t.HalfYearTax != 0 {
+			return nil, apperror.New(apperror.ConflictingFields, "halfYearTax", "halfYearTax can only be credited in annual period")
+		}
+		result, err = calculator.CalculateHalfYearTax(t.Incomes, t.WHT, t.Allowances)
+	case "", "annual":
+		if fromIncomes {
+			if t.TotalIncome != 0 {
+				return nil, apperror.New(apperror.ConflictingFields, "totalIncome", "totalIncome cannot be used together with incomes or investments")
+			}
+			incomes := t.Incomes
+			if len(t.ForeignIncomes) > 0 {
+				var converted []calculator.Income
+				converted, foreign, err = calculator.ConvertForeignIncomes(t.ForeignIncomes)
+				if err != nil {
+					return nil, err
+				}
+				incomes = append(append([]calculator.Income{}, t.Incomes...), converted...)
+			}
+			result, err = calculator.CalculateTaxFromIncomes(incomes, t.Investments, t.WHT, t.Allowances)
+			if err == nil && len(foreign) > 0 {
+				var credit calculator.ForeignTaxCredit
+				result, credit, err = calculator.CreditForeignTax(result, foreign)
+				foreignTaxCredit = &credit
+			}
+		} else {
+			result, err = calculator.CalculateTaxDetail(t.TotalIncome, t.WHT, t.Allowances)
+		}
+		if err == nil && t.HalfYearTax != 0 {
+			result, err = calculator.CreditHalfYearTax(result, t.HalfYearTax)
+		}
+	default:
+		return nil, apperror.New(apperror.UnknownType, "period", "period must be annual or half-year")
+	}
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, Err{Message: err.Error()})
+		return nil, err
+	}
+
+	var taxMethod *calculator.TaxMethod
+	if fromIncomes {
+		taxMethod = &result.TaxMethod
+	}
+	period := ""
+	if t.Period == "half-year" {
+		period = t.Period
+	}
+
+	if !explain {
+		result.Trace = nil
 	}
 
-	tax, taxLevels, err := calculator.CalculateTax(t.TotalIncome, t.WHT, t.Allowances)
+	// A refund has nothing to pay, so paymentDate and installments are
+	// ignored.
+	var payment *calculator.Payment
+	if result.Tax >= 0 && (t.PaymentDate != "" || t.Installments) {
+		p, err := calculator.CalculatePayment(result.Tax, period, t.PaymentDate, t.Installments)
+		if err != nil {
+			return nil, err
+		}
+		payment = &p
+	}
+
+	if result.Tax < 0 {
+		res := TaxRefundRespond{
+			Taxpayer:         taxpayer,
+			TaxRefund:        -result.Tax,
+			TaxLevels:        result.TaxLevels,
+			TaxRate:          result.TaxRate,
+			Incomes:          result.Incomes,
+			TaxMethod:        taxMethod,
+			Investment:       result.Investment,
+			ForeignIncomes:   foreign,
+			ForeignTaxCredit: foreignTaxCredit,
+			Period:           period,
+			Explanation:      result.Trace,
+		}
+		return res, nil
+	}
+	res := TaxResponse{
+		Taxpayer:         taxpayer,
+		Tax:              result.Tax,
+		TaxLevels:        result.TaxLevels,
+		TaxRate:          result.TaxRate,
+		Incomes:          result.Incomes,
+		TaxMethod:        taxMethod,
+		Investment:       result.Investment,
+		ForeignIncomes:   foreign,
+		ForeignTaxCredit: foreignTaxCredit,
+		Period:           period,
+		Payment:          payment,
+		Explanation:      result.Trace,
+	}
+	return res, nil
+}
+
+// validateRequest reports every problem of the taxpayer, amounts and
+// allowances at once, before the calculation stops at the first one.
+func validateRequest(t TaxRequest) (calculator.Taxpayer, apperror.Errors) {
+	errs := apperror.Errors{}
+	taxpayer, err := calculator.ValidateTaxpayer(t.Taxpayer)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, Err{Message: err.Error()})
+		errs = append(errs, apperror.As(err, apperror.InvalidRequest))
 	}
 
-	if tax < 0 {
-		res := TaxRefundRespond{TaxRefund: -tax, TaxLevels: taxLevels}
-		return c.JSON(http.StatusOK, res)
+	if len(t.Incomes) > 0 || len(t.Investments) > 0 || len(t.ForeignIncomes) > 0 {
+		errs = append(errs, calculator.ValidateIncomeInput(t.Incomes, t.Investments, t.ForeignIncomes, t.WHT, t.Allowances)...)
 	} else {
-		res := TaxResponse{Tax: tax, TaxLevels: taxLevels}
-		return c.JSON(http.StatusOK, res)
+		errs = append(errs, calculator.ValidateTaxInput(t.TotalIncome, t.WHT, t.Allowances)...)
 	}
+	return taxpayer, errs
 }
@@ -3,12 +3,17 @@ package taxHandler
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/TonRat/assessment-tax/apperror"
 	"github.com/TonRat/assessment-tax/calculator"
 	"github.com/labstack/echo/v4"
 )
@@ -48,6 +53,685 @@ func TestCalculateTaxHandler(t *testing.T) {
 			{Level: "1,000,001-2,000,000", Tax: 0.0},
 			{Level: "2,000,001 ขึ้นไป", Tax: 0.0},
 		},
+		TaxRate: calculator.TaxRate{
+			MarginalRate:  0.1,
+			EffectiveRate: 0.038,
+			Level:         "150,001-500,000",
+			NextLevel:     "500,001-1,000,000",
+			NextRate:      0.15,
+			Headroom:      160000.0,
+		},
 	}
 	assert.Equal(t, expectedRes, res)
 }
+
+func TestCalculateTaxHandlerExplain(t *testing.T) {
+	reqJSON := `{"totalIncome": 500000.0, "wht": 0.0, "allowances": [{"allowanceType": "donation", "amount": 200000.0}]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations?explain=true", bytes.NewBufferString(reqJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	err := CalculateTaxHandler(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res TaxResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 19000.0, res.Tax)
+	assert.Equal(t, calculator.Step{Step: "gross income", Amount: 500000.0}, res.Explanation[0])
+	assert.Equal(t, calculator.Step{Step: "donation", Requested: 200000.0, Amount: 100000.0, Cap: 100000.0, Note: "clipped by donation cap"}, res.Explanation[2])
+	assert.Equal(t, calculator.Step{Step: "tax", Amount: 19000.0}, res.Explanation[len(res.Explanation)-1])
+}
+
+func TestCalculateTaxHandlerPayment(t *testing.T) {
+	calculate := func(reqJSON string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewBufferString(reqJSON))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		err := CalculateTaxHandler(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var res map[string]interface{}
+		err = json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+		return res
+	}
+
+	t.Run("Tax", func(t *testing.T) {
+		res := calculate(`{"totalIncome": 500000.0, "wht": 0.0, "paymentDate": "2025-05-01", "installments": true}`)
+
+		payment, _ := res["payment"].(map[string]interface{})
+		assert.Equal(t, 870.0, payment["surcharge"], "Wrong surcharge")
+		assert.Equal(t, 29870.0, payment["total"], "Wrong total")
+		assert.Len(t, payment["installments"], 3, "Wrong installments")
+	})
+
+	t.Run("Refund", func(t *testing.T) {
+		res := calculate(`{"totalIncome": 500000.0, "wht": 50000.0, "paymentDate": "2025-05-01", "installments": true}`)
+
+		assert.Equal(t, 21000.0, res["taxRefund"], "Wrong refund")
+		assert.NotContains(t, res, "payment", "Refund should have no payment")
+	})
+}
+
+func TestCalculateTaxHandlerTaxpayer(t *testing.T) {
+	t.Run("ReturnTaxpayer", func(t *testing.T) {
+		reqJSON := `{"taxpayerId": "1-1017-00203-94-8", "firstName": "Somchai", "lastName": "Jaidee", "totalIncome": 500000.0, "wht": 0.0, "allowances": []}`
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewBufferString(reqJSON))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		err := CalculateTaxHandler(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var res TaxResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, calculator.Taxpayer{TaxpayerID: "1101700203948", FirstName: "Somchai", LastName: "Jaidee"}, res.Taxpayer)
+		assert.Equal(t, 29000.0, res.Tax)
+	})
+
+	t.Run("InvalidTaxpayerID", func(t *testing.T) {
+		reqJSON := `{"taxpayerId": "1101700203949", "totalIncome": 500000.0, "wht": 0.0, "allowances": []}`
+
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewBufferString(reqJSON))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		err := CalculateTaxHandler(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var res apperror.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		expectedRes := apperror.Problem{
+			Type:     "urn:assessment-tax:error:invalid_checksum",
+			Title:    "Bad Request",
+			Status:   http.StatusBadRequest,
+			Detail:   "taxpayerId check digit is invalid",
+			Instance: "/tax/calculations",
+			Code:     apperror.InvalidChecksum,
+			Field:    "taxpayerId",
+		}
+		assert.Equal(t, apperror.ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, expectedRes, res)
+	})
+}
+
+func TestCalculateTaxHandlerLanguage(t *testing.T) {
+	calculate := func(lang, reqJSON string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations?explain=true", bytes.NewBufferString(reqJSON))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", lang)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		err := CalculateTaxHandler(c)
+		assert.NoError(t, err)
+		return rec
+	}
+
+	t.Run("English", func(t *testing.T) {
+		rec := calculate("en-US,en;q=0.9", `{"totalIncome": 500000.0, "wht": 0.0, "allowances": [{"allowanceType": "donation", "amount": 200000.0}]}`)
+
+		var res TaxResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+		assert.Equal(t, "2,000,001 and above", res.TaxLevels[4].Level)
+		assert.Equal(t, "clipped by donation cap", res.Explanation[2].Note)
+	})
+
+	t.Run("Thai", func(t *testing.T) {
+		rec := calculate("th", `{"totalIncome": 500000.0, "wht": 0.0, "allowances": [{"allowanceType": "donation", "amount": 200000.0}]}`)
+
+		var res TaxResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "2,000,001 ขึ้นไป", res.TaxLevels[4].Level)
+		assert.Equal(t, calculator.Step{Step: "เงินบริจาค", Requested: 200000.0, Amount: 100000.0, Cap: 100000.0, Note: "หักได้ไม่เกินเพดานเงินบริจาค"}, res.Explanation[2])
+	})
+
+	t.Run("ThaiError", func(t *testing.T) {
+		rec := calculate("th", `{"totalIncome": 500000.0, "wht": -1.0, "allowances": []}`)
+
+		var res apperror.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "คำขอไม่ถูกต้อง", res.Title)
+		assert.Equal(t, "wht ต้องอยู่ระหว่าง 0 ถึง totalIncome", res.Detail)
+		assert.Equal(t, apperror.OutOfRange, res.Code)
+	})
+}
+
+func TestCalculateTaxHandlerValidation(t *testing.T) {
+	reqJSON := `{"taxpayerId": "1101700203949", "totalIncome": -1.0, "wht": 0.0, "allowances": [{"allowanceType": "donation", "amount": 100.0}, {"allowanceType": "donation", "amount": -100.0}]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewBufferString(reqJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	err := CalculateTaxHandler(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res apperror.Problem
+	err = json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	expectedErrors := []apperror.ProblemError{
+		{Code: apperror.InvalidChecksum, Field: "taxpayerId", Detail: "taxpayerId check digit is invalid"},
+		{Code: apperror.OutOfRange, Field: "totalIncome", Detail: "totalIncome must be greater than or equal to 0"},
+		{Code: apperror.ConflictingFields, Field: "allowances[1].allowanceType", Detail: "allowance donation is given more than once"},
+		{Code: apperror.OutOfRange, Field: "allowances[1].amount", Detail: "allowances[1].amount must be greater than or equal to 0"},
+	}
+	assert.Equal(t, apperror.ValidationFailed, res.Code)
+	assert.Equal(t, "request has 4 problems", res.Detail)
+	assert.Equal(t, expectedErrors, res.Errors)
+}
+
+func TestCalculateTaxHandlerValidationIncomes(t *testing.T) {
+	reqJSON := `{"incomes": [{"incomeType": "40(1)", "amount": 100000.0}, {"incomeType": "40(2)", "amount": -1.0}], "investments": [{"investmentType": "dividend", "amount": -1.0}], "wht": -1.0}`
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewBufferString(reqJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	err := CalculateTaxHandler(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res apperror.Problem
+	err = json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	expectedErrors := []apperror.ProblemError{
+		{Code: apperror.OutOfRange, Field: "incomes[1].amount", Detail: "incomes[1].amount must be greater than or equal to 0"},
+		{Code: apperror.OutOfRange, Field: "investments[0].amount", Detail: "investments[0].amount must be greater than or equal to 0"},
+		{Code: apperror.OutOfRange, Field: "wht", Detail: "wht must be between 0 and totalIncome"},
+	}
+	assert.Equal(t, apperror.ValidationFailed, res.Code)
+	assert.Equal(t, expectedErrors, res.Errors)
+}
+
+func TestCalculateTaxHandlerStrict(t *testing.T) {
+	reqJSON := `{"totalincome": 500000.0, "wht": "0", "allowances": [{"allowanceType": "donation"}]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewBufferString(reqJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	err := CalculateTaxHandler(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res apperror.Problem
+	err = json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	expectedErrors := []apperror.ProblemError{
+		{Code: apperror.MissingField, Field: "allowances[0].amount", Detail: "allowances[0].amount is required"},
+		{Code: apperror.UnknownField, Field: "totalincome", Detail: "totalincome is not a known field"},
+		{Code: apperror.InvalidType, Field: "wht", Detail: "wht must be a number"},
+	}
+	assert.Equal(t, apperror.ValidationFailed, res.Code)
+	assert.Equal(t, expectedErrors, res.Errors)
+}
+
+func TestReverseCalculateHandler(t *testing.T) {
+	reqJSON := `{"target": "net", "amount": 471000.0, "allowances": []}`
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations/reverse", bytes.NewBufferString(reqJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	err := ReverseCalculateHandler(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res ReverseResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 500000.0, res.TotalIncome)
+	assert.Equal(t, 29000.0, res.Tax)
+	assert.Equal(t, 471000.0, res.NetIncome)
+}
+
+func TestStrictHandlers(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		reqJSON string
+		code    apperror.Code
+		field   string
+	}{
+		{name: "Reverse", handler: ReverseCalculateHandler, reqJSON: `{"target": "net", "amout": 100.0}`, code: apperror.ValidationFailed},
+		{name: "ReverseMissingAmount", handler: ReverseCalculateHandler, reqJSON: `{"target": "net"}`, code: apperror.MissingField, field: "amount"},
+		{name: "Household", handler: HouseholdHandler, reqJSON: `{"spouse1": {"wht": 0.0, "income": []}, "spouse2": {"wht": 0.0}}`, code: apperror.UnknownField, field: "spouse1.income"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.reqJSON))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			err := tt.handler(c)
+			assert.NoError(t, err)
+
+			var res apperror.Problem
+			err = json.Unmarshal(rec.Body.Bytes(), &res)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, tt.code, res.Code)
+			assert.Equal(t, tt.field, res.Field)
+		})
+	}
+}
+
+func TestScenarioHandler(t *testing.T) {
+	reqJSON := `{
+		"base": {"totalIncome": 500000.0, "wht": 0.0, "allowances": [{"allowanceType": "donation", "amount": 0.0}]},
+		"scenarios": [
+			{"name": "k-receipt", "allowances": [{"allowanceType": "k-receipt", "amount": 50000.0}]},
+			{"name": "more wht", "wht": 40000.0}
+		]
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/scenarios", bytes.NewBufferString(reqJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	err := ScenarioHandler(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res struct {
+		Scenarios []struct {
+			Name string      `json:"name"`
+			Diff []FieldDiff `json:"diff"`
+		} `json:"scenarios"`
+	}
+	err = json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	change := -5000.0
+	assert.Equal(t, "k-receipt", res.Scenarios[0].Name)
+	assert.Contains(t, res.Scenarios[0].Diff, FieldDiff{Field: "tax", Base: 29000.0, Scenario: 24000.0, Change: &change})
+
+	assert.Equal(t, "more wht", res.Scenarios[1].Name)
+	assert.Contains(t, res.Scenarios[1].Diff, FieldDiff{Field: "tax", Base: 29000.0, Scenario: nil})
+	assert.Contains(t, res.Scenarios[1].Diff, FieldDiff{Field: "taxRefund", Base: nil, Scenario: 11000.0})
+}
+
+func TestHouseholdHandlerAllowances(t *testing.T) {
+	reqJSON := `{"spouse1": {"wht": 0.0, "allowances": [{"allowanceType": "donation", "amount": 100.0}, {"allowanceType": "donation", "amount": 200.0}]}, "spouse2": {"wht": 0.0, "allowances": [{"allowanceType": "lottery", "amount": 100.0}]}}`
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/household", bytes.NewBufferString(reqJSON))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	err := HouseholdHandler(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res apperror.Problem
+	err = json.Unmarshal(rec.Body.Bytes(), &res)
+	assert.NoError(t, err)
+
+	expectedErrors := []apperror.ProblemError{
+		{Code: apperror.ConflictingFields, Field: "spouse1.allowances[1].allowanceType", Detail: "spouse1: allowance donation is given more than once"},
+		{Code: apperror.UnknownType, Field: "spouse2.allowances[0].allowanceType", Detail: "spouse2: allowanceType must be one of spouse, k-receipt, donation"},
+	}
+	assert.Equal(t, apperror.ValidationFailed, res.Code)
+	assert.Equal(t, expectedErrors, res.Errors)
+}
+
+func TestAdviceHandler(t *testing.T) {
+	advise := func(reqJSON string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tax/advice", bytes.NewBufferString(reqJSON))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		err := AdviceHandler(c)
+		assert.NoError(t, err)
+		return rec
+	}
+
+	t.Run("Advice", func(t *testing.T) {
+		rec := advise(`{"totalIncome": 600000.0, "wht": 0.0, "allowances": [], "period": "annual"}`)
+
+		var res AdviceResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 41000.0, res.Tax, "Tax should be 41000.0")
+		assert.Len(t, res.Advice, 2)
+	})
+
+	unsupported := []struct {
+		name    string
+		reqJSON string
+		field   string
+		code    apperror.Code
+	}{
+		{name: "ForeignIncomes", reqJSON: `{"wht": 0.0, "foreignIncomes": [{"incomeType": "40(2)", "currency": "USD", "amount": 10000.0, "remittanceDate": "2024-05-15"}]}`, field: "foreignIncomes", code: apperror.ConflictingFields},
+		{name: "HalfYear", reqJSON: `{"wht": 0.0, "incomes": [{"incomeType": "40(8)", "amount": 1000000.0}], "period": "half-year"}`, field: "period", code: apperror.ConflictingFields},
+		{name: "UnknownPeriod", reqJSON: `{"totalIncome": 600000.0, "wht": 0.0, "period": "monthly"}`, field: "period", code: apperror.UnknownType},
+		{name: "HalfYearTax", reqJSON: `{"totalIncome": 600000.0, "wht": 0.0, "halfYearTax": 1000.0}`, field: "halfYearTax", code: apperror.ConflictingFields},
+		{name: "PaymentDate", reqJSON: `{"totalIncome": 600000.0, "wht": 0.0, "paymentDate": "2025-04-01"}`, field: "paymentDate", code: apperror.ConflictingFields},
+		{name: "Installments", reqJSON: `{"totalIncome": 600000.0, "wht": 0.0, "installments": true}`, field: "installments", code: apperror.ConflictingFields},
+	}
+	for _, tt := range unsupported {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := advise(tt.reqJSON)
+
+			var res apperror.Problem
+			err := json.Unmarshal(rec.Body.Bytes(), &res)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, tt.code, res.Code)
+			assert.Equal(t, tt.field, res.Field)
+		})
+	}
+
+	t.Run("Allowances", func(t *testing.T) {
+		rec := advise(`{"totalIncome": 600000.0, "wht": 0.0, "allowances": [{"allowanceType": "donation", "amount": 100.0}, {"allowanceType": "donation", "amount": 200.0}, {"allowanceType": "lottery", "amount": 100.0}], "paymentDate": "2025-04-01"}`)
+
+		var res apperror.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		expectedErrors := []apperror.ProblemError{
+			{Code: apperror.ConflictingFields, Field: "allowances[1].allowanceType", Detail: "allowance donation is given more than once"},
+			{Code: apperror.UnknownType, Field: "allowances[2].allowanceType", Detail: "allowanceType must be one of spouse, k-receipt, donation"},
+			{Code: apperror.ConflictingFields, Field: "paymentDate", Detail: "paymentDate cannot be used with advice"},
+		}
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, apperror.ValidationFailed, res.Code)
+		assert.Equal(t, expectedErrors, res.Errors)
+	})
+}
+
+func TestCalculateTaxV2Handler(t *testing.T) {
+	tests := []struct {
+		name      string
+		reqJSON   string
+		tax       float64
+		taxRefund float64
+	}{
+		{name: "Tax", reqJSON: `{"totalIncome": 500000.0, "wht": 0.0, "allowances": []}`, tax: 29000.0, taxRefund: 0.0},
+		{name: "Refund", reqJSON: `{"totalIncome": 500000.0, "wht": 40000.0, "allowances": []}`, tax: 0.0, taxRefund: 11000.0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v2/tax/calculations", bytes.NewBufferString(test.reqJSON))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			err := CalculateTaxV2Handler(c)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var res map[string]interface{}
+			err = json.Unmarshal(rec.Body.Bytes(), &res)
+			assert.NoError(t, err)
+
+			assert.Equal(t, test.tax, res["tax"], "Wrong tax")
+			assert.Equal(t, test.taxRefund, res["taxRefund"], "Wrong tax refund")
+			assert.Len(t, res["taxLevel"], 5, "Should have taxLevel")
+			assert.NotContains(t, res, "taxlevel", "Should not have taxlevel")
+		})
+	}
+}
+
+func TestBatchCalculateTaxHandler(t *testing.T) {
+	batch := func(reqJSON string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/batch", bytes.NewBufferString(reqJSON))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		err := BatchCalculateTaxHandler(c)
+		assert.NoError(t, err)
+		return rec
+	}
+
+	t.Run("InputOrder", func(t *testing.T) {
+		concurrency := BatchConcurrency
+		BatchConcurrency = 2
+		defer func() { BatchConcurrency = concurrency }()
+
+		rec := batch(`[
+			{"id": "a", "totalIncome": 500000.0, "wht": 0.0, "allowances": []},
+			{"id": "b", "totalIncome": 500000.0, "wht": 40000.0, "allowances": []},
+			{"id": "c", "totalIncome": 500000.0, "wht": 600000.0, "allowances": []},
+			{"id": "d", "incomes": [{"incomeType": "40(8)", "amount": 2000000.0}], "wht": 0.0}
+		]`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var res BatchResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		ids := []string{}
+		for _, result := range res.Results {
+			ids = append(ids, result.ID)
+		}
+		assert.Equal(t, []string{"a", "b", "c", "d"}, ids, "Results should be in input order")
+		assert.Equal(t, 29000.0, res.Results[0].Result.Tax)
+		assert.Equal(t, 11000.0, res.Results[1].Result.TaxRefund)
+		assert.Nil(t, res.Results[2].Result)
+		assert.Equal(t, apperror.OutOfRange, res.Results[2].Error.Code)
+		assert.Equal(t, "wht", res.Results[2].Error.Field)
+		assert.Equal(t, 71000.0, res.Results[3].Result.Tax)
+		assert.Equal(t, 3, res.Succeeded)
+		assert.Equal(t, 1, res.Failed)
+	})
+
+	t.Run("UndecodableItems", func(t *testing.T) {
+		rec := batch(`[
+			{"id": "a", "totalIncome": 500000.0, "wht": 0.0},
+			{"id": "b", "totalincome": 500000.0, "wht": 0.0},
+			{"id": "c", "totalIncome": "500000", "wht": 0.0},
+			{"totalIncome": 500000.0, "wht": 0.0},
+			null
+		]`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var res BatchResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 29000.0, res.Results[0].Result.Tax)
+		assert.Equal(t, "b", res.Results[1].ID, "Should match the problem by id")
+		assert.Equal(t, apperror.UnknownField, res.Results[1].Error.Code)
+		assert.Equal(t, "totalincome", res.Results[1].Error.Field)
+		assert.Equal(t, apperror.InvalidType, res.Results[2].Error.Code)
+		assert.Equal(t, "totalIncome", res.Results[2].Error.Field)
+		assert.Equal(t, apperror.MissingField, res.Results[3].Error.Code)
+		assert.Equal(t, "id", res.Results[3].Error.Field)
+		assert.Equal(t, apperror.InvalidType, res.Results[4].Error.Code)
+		assert.Equal(t, 1, res.Succeeded)
+		assert.Equal(t, 4, res.Failed)
+	})
+
+	t.Run("NotAnArray", func(t *testing.T) {
+		rec := batch(`{"id": "a", "totalIncome": 500000.0, "wht": 0.0}`)
+
+		var res apperror.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, apperror.InvalidType, res.Code)
+	})
+
+	t.Run("DuplicateID", func(t *testing.T) {
+		rec := batch(`[{"id": "a", "totalIncome": 500000.0, "wht": 0.0}, {"id": "a", "totalIncome": 600000.0, "wht": 0.0}]`)
+
+		var res apperror.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &res)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, apperror.ConflictingFields, res.Code)
+		assert.Equal(t, "[1].id", res.Field)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		rec := batch(`[]`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "batch must have 1 to 1000 calculations")
+	})
+}
+
+func TestStreamCalculateTaxHandler(t *testing.T) {
+	type line struct {
+		Line    int               `json:"line"`
+		ID      string            `json:"id"`
+		Result  json.RawMessage   `json:"result"`
+		Error   *apperror.Problem `json:"error"`
+		Summary *StreamSummary    `json:"summary"`
+	}
+	streamBody := func(contentType string, body io.Reader) (*httptest.ResponseRecorder, []line) {
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/stream", body)
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		err := StreamCalculateTaxHandler(c)
+		assert.NoError(t, err)
+
+		lines := []line{}
+		decoder := json.NewDecoder(bytes.NewReader(rec.Body.Bytes()))
+		for decoder.More() {
+			var l line
+			err := decoder.Decode(&l)
+			assert.NoError(t, err)
+			lines = append(lines, l)
+		}
+		return rec, lines
+	}
+	stream := func(contentType, body string) (*httptest.ResponseRecorder, []line) {
+		return streamBody(contentType, strings.NewReader(body))
+	}
+
+	t.Run("NDJSON", func(t *testing.T) {
+		rec, lines := stream("application/x-ndjson", `{"id": "a", "totalIncome": 500000.0, "wht": 0.0, "allowances": []}
+
+{"totalincome": 500000.0, "wht": 0.0}
+{"id": "c", "totalIncome": 500000.0, "wht": 600000.0}
+{"totalIncome": 500000.0, "wht": 40000.0}
+`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+		assert.Len(t, lines, 5)
+
+		var res TaxResponseV2
+		err := json.Unmarshal(lines[0].Result, &res)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, lines[0].Line)
+		assert.Equal(t, "a", lines[0].ID)
+		assert.Equal(t, 29000.0, res.Tax)
+
+		assert.Equal(t, 3, lines[1].Line, "Blank lines should be counted but skipped")
+		assert.Equal(t, apperror.UnknownField, lines[1].Error.Code)
+		assert.Equal(t, "totalincome", lines[1].Error.Field)
+
+		assert.Equal(t, "c", lines[2].ID)
+		assert.Equal(t, apperror.OutOfRange, lines[2].Error.Code)
+
+		err = json.Unmarshal(lines[3].Result, &res)
+		assert.NoError(t, err)
+		assert.Equal(t, 11000.0, res.TaxRefund)
+
+		assert.Equal(t, &StreamSummary{Lines: 4, Succeeded: 2, Failed: 2}, lines[4].Summary)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		rec, lines := stream("text/csv; charset=utf-8", "totalIncome,wht,donation\n500000,0,0\nx,0,0\n600000,40000,20000\n")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, lines, 4)
+
+		var record struct {
+			Tax       float64 `json:"tax"`
+			TaxRefund float64 `json:"taxRefund"`
+		}
+		err := json.Unmarshal(lines[0].Result, &record)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, lines[0].Line)
+		assert.Equal(t, 29000.0, record.Tax)
+
+		assert.Equal(t, 3, lines[1].Line)
+		assert.Equal(t, apperror.InvalidFormat, lines[1].Error.Code)
+
+		err = json.Unmarshal(lines[2].Result, &record)
+		assert.NoError(t, err)
+		assert.Equal(t, 2000.0, record.TaxRefund)
+
+		assert.Equal(t, &StreamSummary{Lines: 3, Succeeded: 2, Failed: 1}, lines[3].Summary)
+	})
+
+	t.Run("UnparsableRows", func(t *testing.T) {
+		long := "1,2," + strings.Repeat("9", MaxStreamLine)
+		_, lines := stream("text/csv", "totalIncome,wht,donation\n1,2\n\"500000,0,0\n"+long+"\n600000,0,0\n")
+		assert.Len(t, lines, 5)
+
+		for i, row := range []int{2, 3, 4} {
+			assert.Equal(t, row, lines[i].Line)
+			assert.Equal(t, apperror.InvalidCSV, lines[i].Error.Code, "Row %d should fail", row)
+		}
+		assert.Equal(t, "row must have as many fields as the header", lines[0].Error.Detail)
+		assert.Equal(t, "row is longer than 1 MiB", lines[2].Error.Detail)
+
+		var record struct {
+			Tax float64 `json:"tax"`
+		}
+		err := json.Unmarshal(lines[3].Result, &record)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, lines[3].Line)
+		assert.Equal(t, 41000.0, record.Tax, "Rows after the bad ones should be calculated")
+
+		assert.Equal(t, &StreamSummary{Lines: 4, Succeeded: 1, Failed: 3}, lines[4].Summary)
+	})
+
+	t.Run("UnreadableBody", func(t *testing.T) {
+		body := io.MultiReader(strings.NewReader("totalIncome,wht,donation\n500000,0,0\n"), iotest.ErrReader(errors.New("connection reset")))
+		_, lines := streamBody("text/csv", body)
+		assert.Len(t, lines, 2)
+
+		summary := lines[1].Summary
+		assert.Equal(t, 1, summary.Lines)
+		assert.Equal(t, apperror.InvalidCSV, summary.Error.Code)
+	})
+
+	t.Run("InvalidHeader", func(t *testing.T) {
+		rec, _ := stream("text/csv", "income,wht,donation\n500000,0,0\n")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "invalid CSV header format")
+	})
+
+	t.Run("UnsupportedContentType", func(t *testing.T) {
+		rec, _ := stream("application/json", `{"totalIncome": 500000.0, "wht": 0.0}`)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Content-Type must be application/x-ndjson or text/csv")
+	})
+}
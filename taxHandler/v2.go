@@ -0,0 +1,76 @@
+package taxHandler
+
+import (
+	"github.com/TonRat/assessment-tax/apperror"
+	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/i18n"
+	"github.com/TonRat/assessment-tax/strictjson"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// TaxResponseV2 has one shape for tax and refund: tax and taxRefund are both
+// always present and at most one of them is more than 0.
+type TaxResponseV2 struct {
+	calculator.Taxpayer
+	Tax              float64                          `json:"tax"`
+	TaxRefund        float64                          `json:"taxRefund"`
+	TaxLevels        []calculator.TaxLevel            `json:"taxLevel"`
+	TaxRate          calculator.TaxRate               `json:"taxRate"`
+	Incomes          []calculator.IncomeDetail        `json:"incomes,omitempty"`
+	TaxMethod        *calculator.TaxMethod            `json:"taxMethod,omitempty"`
+	Investment       *calculator.InvestmentElection   `json:"investment,omitempty"`
+	ForeignIncomes   []calculator.ForeignIncomeDetail `json:"foreignIncomes,omitempty"`
+	ForeignTaxCredit *calculator.ForeignTaxCredit     `json:"foreignTaxCredit,omitempty"`
+	Period           string                           `json:"period,omitempty"`
+	Payment          *calculator.Payment              `json:"payment,omitempty"`
+	Explanation      []calculator.Step                `json:"explanation,omitempty"`
+}
+
+func CalculateTaxV2Handler(c echo.Context) error {
+	var t TaxRequest
+	err := strictjson.Bind(c, &t)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	res, err := calculateTax(t, c.QueryParam("explain") == "true")
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+	return c.JSON(http.StatusOK, responseV2(localize(i18n.Negotiate(c.Request(), c.Response()), res)))
+}
+
+// responseV2 converts a calculateTax result to TaxResponseV2.
+func responseV2(res interface{}) TaxResponseV2 {
+	if r, ok := res.(TaxRefundRespond); ok {
+		return TaxResponseV2{
+			Taxpayer:         r.Taxpayer,
+			TaxRefund:        r.TaxRefund,
+			TaxLevels:        r.TaxLevels,
+			TaxRate:          r.TaxRate,
+			Incomes:          r.Incomes,
+			TaxMethod:        r.TaxMethod,
+			Investment:       r.Investment,
+			ForeignIncomes:   r.ForeignIncomes,
+			ForeignTaxCredit: r.ForeignTaxCredit,
+			Period:           r.Period,
+			Explanation:      r.Explanation,
+		}
+	}
+	r := res.(TaxResponse)
+	return TaxResponseV2{
+		Taxpayer:         r.Taxpayer,
+		Tax:              r.Tax,
+		TaxLevels:        r.TaxLevels,
+		TaxRate:          r.TaxRate,
+		Incomes:          r.Incomes,
+		TaxMethod:        r.TaxMethod,
+		Investment:       r.Investment,
+		ForeignIncomes:   r.ForeignIncomes,
+		ForeignTaxCredit: r.ForeignTaxCredit,
+		Period:           r.Period,
+		Payment:          r.Payment,
+		Explanation:      r.Explanation,
+	}
+}
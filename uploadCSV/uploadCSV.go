@@ -1,101 +1,335 @@
 package uploadcsv
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/csv"
+	"errors"
+	"github.com/TonRat/assessment-tax/apperror"
 	"github.com/TonRat/assessment-tax/calculator"
+	"github.com/TonRat/assessment-tax/i18n"
 	"github.com/labstack/echo/v4"
 	"io"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 )
 
 type TaxRecord struct {
-	TotalIncome float64 `json:"totalIncome"`
-	Tax         float64 `json:"tax"`
+	calculator.Taxpayer
+	TotalIncome float64            `json:"totalIncome"`
+	Tax         float64            `json:"tax"`
+	TaxRate     calculator.TaxRate `json:"taxRate"`
+	Explanation []calculator.Step  `json:"explanation,omitempty"`
 }
 
 type TaxRecordRefund struct {
-	TotalIncome float64 `json:"totalIncome"`
-	TaxRefund   float64 `json:"taxRefund"`
+	calculator.Taxpayer
+	TotalIncome float64            `json:"totalIncome"`
+	TaxRefund   float64            `json:"taxRefund"`
+	TaxRate     calculator.TaxRate `json:"taxRate"`
+	Explanation []calculator.Step  `json:"explanation,omitempty"`
 }
 
 type TaxResponseCSV struct {
 	Taxes []interface{} `json:"taxes"`
 }
 
-type Err struct {
-	Message string `json:"message"`
+var errInvalidHeader = apperror.New(apperror.InvalidCSV, "", "invalid CSV header format")
+
+// taxpayerColumns may follow the required columns in any order.
+var taxpayerColumns = []string{"taxpayerId", "firstName", "lastName"}
+
+// TaxRecordV2 has one shape for tax and refund, like
+// taxHandler.TaxResponseV2.
+type TaxRecordV2 struct {
+	calculator.Taxpayer
+	TotalIncome float64            `json:"totalIncome"`
+	Tax         float64            `json:"tax"`
+	TaxRefund   float64            `json:"taxRefund"`
+	TaxRate     calculator.TaxRate `json:"taxRate"`
+	Explanation []calculator.Step  `json:"explanation,omitempty"`
+}
+
+type TaxResponseCSVV2 struct {
+	Taxes []TaxRecordV2 `json:"taxes"`
 }
 
 func UploadCSVHandler(c echo.Context) error {
+	records, err := calculateRecords(c)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	var taxes []interface{}
+	for _, record := range records {
+		if record.TaxRefund > 0 {
+			refundRecord := TaxRecordRefund{Taxpayer: record.Taxpayer, TotalIncome: record.TotalIncome, TaxRefund: record.TaxRefund, TaxRate: record.TaxRate, Explanation: record.Explanation}
+			taxes = append(taxes, refundRecord)
+		} else {
+			normalRecord := TaxRecord{Taxpayer: record.Taxpayer, TotalIncome: record.TotalIncome, Tax: record.Tax, TaxRate: record.TaxRate, Explanation: record.Explanation}
+			taxes = append(taxes, normalRecord)
+		}
+	}
+
+	res := TaxResponseCSV{Taxes: taxes}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func UploadCSVV2Handler(c echo.Context) error {
+	records, err := calculateRecords(c)
+	if err != nil {
+		return apperror.Respond(c, http.StatusBadRequest, err)
+	}
+
+	res := TaxResponseCSVV2{Taxes: records}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// calculateRecords calculates the tax of every row of the uploaded taxFile.
+func calculateRecords(c echo.Context) ([]TaxRecordV2, error) {
+	explain := c.QueryParam("explain") == "true"
+	lang := i18n.Negotiate(c.Request(), c.Response())
+
 	// Get uploaded file
 	file, err := c.FormFile("taxFile")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, Err{Message: err.Error()})
+		return nil, err
 	}
 	// Open the uploaded file
 	src, err := file.Open()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, Err{Message: err.Error()})
+		return nil, err
 	}
 	defer src.Close()
-	// Create a CSV reader
-	reader := csv.NewReader(src)
-	// Read the header row
-	header, err := reader.Read()
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, Err{Message: "Failed to read CSV header"})
-	}
+	return ReadTaxRecords(src, lang, explain)
+}
 
-	// Check if the header matches the expected format
-	expectedHeader := []string{"totalIncome", "wht", "donation"}
-	for i, col := range header {
-		if col != expectedHeader[i] {
-			return c.JSON(http.StatusBadRequest, Err{Message: "Invalid CSV header format"})
-		}
+// ReadTaxRecords calculates the tax of every row of a CSV file with the
+// header totalIncome,wht,donation and the optional taxpayer columns. The
+// problems of all rows are returned at once.
+func ReadTaxRecords(r io.Reader, lang string, explain bool) ([]TaxRecordV2, error) {
+	reader, err := NewReader(r, lang, explain)
+	if err != nil {
+		return nil, err
 	}
 
-	// Read and process CSV records
-	var taxes []interface{}
+	// Read and process CSV records, reporting the problems of all rows at once
+	taxes := []TaxRecordV2{}
+	errs := apperror.Errors{}
 	for {
-		record, err := reader.Read()
+		taxRecord, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
+		var rowErrs apperror.Errors
+		if errors.As(err, &rowErrs) {
+			errs = append(errs, rowErrs...)
+			continue
+		}
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, Err{Message: err.Error()})
+			return nil, err
 		}
+		taxes = append(taxes, taxRecord)
+	}
+	if len(errs) > 0 {
+		return nil, errs.Err()
+	}
+	return taxes, nil
+}
 
-		// Convert CSV data to float64
-		totalIncome, err := strconv.ParseFloat(record[0], 64)
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, Err{Message: "Invalid totalIncome format"})
+// MaxRowSize is the longest row of a CSV file that can be read.
+const MaxRowSize = 1 << 20
+
+var errRowTooLong = errors.New("row too long")
+
+// Reader calculates the tax of a CSV file one row at a time, so a file of
+// any size can be read without holding it in memory. Each row is one line of
+// the file, so quoted fields cannot span lines.
+type Reader struct {
+	lines   *bufio.Reader
+	header  []string
+	columns map[string]int
+	row     int
+	lang    string
+	explain bool
+}
+
+var expectedHeader = []string{"totalIncome", "wht", "donation"}
+
+// NewReader reads and checks the header of the file.
+func NewReader(r io.Reader, lang string, explain bool) (*Reader, error) {
+	reader := &Reader{lines: bufio.NewReader(r), lang: lang, explain: explain}
+	// Read the header row
+	header, err := reader.readRecord()
+	if err != nil {
+		return nil, apperror.New(apperror.InvalidCSV, "", "failed to read CSV header")
+	}
+
+	// Check if the header matches the expected format
+	if len(header) < len(expectedHeader) {
+		return nil, errInvalidHeader
+	}
+	for i, col := range expectedHeader {
+		if header[i] != col {
+			return nil, errInvalidHeader
+		}
+	}
+	columns := map[string]int{}
+	for i, col := range header[len(expectedHeader):] {
+		_, seen := columns[col]
+		if seen || !slices.Contains(taxpayerColumns, col) {
+			return nil, errInvalidHeader
 		}
-		wht, err := strconv.ParseFloat(record[1], 64)
+		columns[col] = len(expectedHeader) + i
+	}
+	reader.header = header
+	reader.columns = columns
+	return reader, nil
+}
+
+// Row is the row of the file last read, counting the header as row 1.
+func (r *Reader) Row() int {
+	return r.row
+}
+
+// Read calculates the tax of the next row, and returns io.EOF after the last
+// one. The problems of a row, including a row that cannot be parsed, are
+// returned as apperror.Errors with the row in their details, and the next row
+// can still be read. Any other error means the rest of the file cannot be
+// read.
+func (r *Reader) Read() (TaxRecordV2, error) {
+	record, err := r.readRecord()
+	if err == io.EOF {
+		return TaxRecordV2{}, err
+	}
+	var pe *csv.ParseError
+	switch {
+	case errors.Is(err, errRowTooLong):
+		return TaxRecordV2{}, apperror.Errors{rowError(r.row, apperror.New(apperror.InvalidCSV, "", "row is longer than 1 MiB"))}
+	case errors.As(err, &pe):
+		return TaxRecordV2{}, apperror.Errors{rowError(r.row, apperror.New(apperror.InvalidCSV, "", pe.Err.Error()))}
+	case err != nil:
+		return TaxRecordV2{}, apperror.New(apperror.InvalidCSV, "", err.Error()).WithDetail("row", r.row)
+	case len(record) != len(r.header):
+		return TaxRecordV2{}, apperror.Errors{rowError(r.row, apperror.New(apperror.InvalidCSV, "", "row must have as many fields as the header"))}
+	}
+
+	// Convert CSV data to float64
+	rowErrs := apperror.Errors{}
+	amounts := make([]float64, len(expectedHeader))
+	for i, col := range expectedHeader {
+		amounts[i], err = strconv.ParseFloat(record[i], 64)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, Err{Message: "Invalid WHT format"})
+			rowErrs = append(rowErrs, apperror.New(apperror.InvalidFormat, col, col+" must be a number"))
+		}
+	}
+	totalIncome, wht, donation := amounts[0], amounts[1], amounts[2]
+	allowances := []calculator.Allowance{{AllowanceType: "donation", Amount: donation}}
+	if len(rowErrs) == 0 {
+		rowErrs = append(rowErrs, calculator.ValidateTaxInput(totalIncome, wht, allowances)...)
+	}
+
+	taxpayer, err := calculator.ValidateTaxpayer(calculator.Taxpayer{
+		TaxpayerID: column(record, r.columns, "taxpayerId"),
+		FirstName:  column(record, r.columns, "firstName"),
+		LastName:   column(record, r.columns, "lastName"),
+	})
+	if err != nil {
+		rowErrs = append(rowErrs, apperror.As(err, apperror.InvalidCSV))
+	}
+
+	if len(rowErrs) > 0 {
+		errs := apperror.Errors{}
+		for _, e := range rowErrs {
+			errs = append(errs, rowError(r.row, e))
 		}
-		donation, err := strconv.ParseFloat(record[2], 64)
+		return TaxRecordV2{}, errs
+	}
+
+	// Perform tax calculation
+	result, err := calculator.CalculateTaxDetail(totalIncome, wht, allowances)
+	if err != nil {
+		return TaxRecordV2{}, apperror.Errors{rowError(r.row, err)}
+	}
+	if !r.explain {
+		result.Trace = nil
+	}
+	taxRecord := TaxRecordV2{
+		Taxpayer:    taxpayer,
+		TotalIncome: totalIncome,
+		Tax:         math.Max(result.Tax, 0),
+		TaxRefund:   math.Max(-result.Tax, 0),
+		TaxRate:     calculator.LocalizeTaxRate(r.lang, result.TaxRate),
+		Explanation: calculator.LocalizeSteps(r.lang, result.Trace),
+	}
+	return taxRecord, nil
+}
+
+// readRecord parses the next line that is not blank. A line longer than
+// MaxRowSize is skipped without being held in memory.
+func (r *Reader) readRecord() ([]string, error) {
+	for {
+		line, err := r.readLine()
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, Err{Message: "Invalid donation format"})
+			return nil, err
 		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		reader := csv.NewReader(bytes.NewReader(line))
+		return reader.Read()
+	}
+}
 
-		// Perform tax calculation
-		allowances := []calculator.Allowance{{AllowanceType: "donation", Amount: donation}}
-		tax, _, err := calculator.CalculateTax(totalIncome, wht, allowances)
+// readLine reads the next line and counts it as a row.
+func (r *Reader) readLine() ([]byte, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, err := r.lines.ReadSlice('\n')
+		if !tooLong && len(line)+len(chunk) > MaxRowSize {
+			tooLong = true
+			line = nil
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && (len(line) > 0 || tooLong) {
+			err = nil
+		}
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, Err{Message: err.Error()})
+			return nil, err
 		}
-		if tax < 0 {
-			refundRecord := TaxRecordRefund{TotalIncome: totalIncome, TaxRefund: -tax}
-			taxes = append(taxes, refundRecord)
-		} else {
-			normalRecord := TaxRecord{TotalIncome: totalIncome, Tax: tax}
-			taxes = append(taxes, normalRecord)
+		r.row++
+		if tooLong {
+			return nil, errRowTooLong
 		}
+		return line, nil
 	}
+}
 
-	res := TaxResponseCSV{Taxes: taxes}
+func column(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok {
+		return ""
+	}
+	return record[i]
+}
 
-	return c.JSON(http.StatusOK, res)
+// rowError adds the row of the record to err. The donation is the only
+// allowance in the file, so allowance errors point at its column.
+func rowError(row int, err error) *apperror.Error {
+	e := apperror.As(err, apperror.InvalidCSV)
+	if strings.HasPrefix(e.Field, "allowances") {
+		e = apperror.New(e.Code, "donation", strings.Replace(e.Message, e.Field, "donation", 1))
+	}
+	return e.WithDetail("row", row)
 }